@@ -0,0 +1,442 @@
+// Command glslcheck validates the shaders of the samples without opening a window.
+//
+// It walks the tree, finds the shader programs created by the samples through
+// shader.NewShader("x.vs", "x.fs"), shader.NewShaderFS(fsys, "x.vs", "x.fs")
+// or a chained shader.NewBuilder().File(stage, "x.vs")...Build(), and checks that
+//
+//   - the shader files exist and their #include directives resolve,
+//   - every input is written by the previous stage with the same type, see shader.CheckInterfaces,
+//   - the uniforms set by name from the Go code are declared in the shaders.
+//
+// The files of NewShaderFS and of the builders with an FS are resolved relative to the directory
+// of the Go file, as the paths of go:embed are.
+//
+// The shader files not referenced by any Go code are paired by name:
+// x.vs with x.fs, and vertex.glsl with fragment.glsl in the same directory.
+//
+// The check is done by a bundled parser of the GLSL declarations, not by a GLSL compiler,
+// so it catches the interface mismatches but not the syntax errors inside functions.
+//
+// Usage:
+//
+//	go run ./cmd/glslcheck [dir ...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ginuerzh/learnopengl/utils/shader"
+)
+
+// program is the stages of a shader program.
+type program struct {
+	files    map[shader.Stage]string // the shader files by stage
+	sources  map[shader.Stage]string // the shader sources given as code by stage
+	defines  map[string]string       // the macros defined by the builder
+	root     string                  // the directory the files are relative to if they are read from a file system
+	pos      string                  // where the program is created, empty if paired by name
+	uniforms map[string]string       // the uniform names set by the Go code and their positions
+}
+
+// stages are the stages by the names of their constants, in pipeline order.
+var stages = []struct {
+	name  string
+	stage shader.Stage
+}{
+	{"VertexStage", shader.VertexStage},
+	{"TessControlStage", shader.TessControlStage},
+	{"TessEvaluationStage", shader.TessEvaluationStage},
+	{"GeometryStage", shader.GeometryStage},
+	{"FragmentStage", shader.FragmentStage},
+	{"ComputeStage", shader.ComputeStage},
+}
+
+func newProgram(pos string) *program {
+	return &program{
+		files:    make(map[shader.Stage]string),
+		sources:  make(map[shader.Stage]string),
+		defines:  make(map[string]string),
+		pos:      pos,
+		uniforms: make(map[string]string),
+	}
+}
+
+// path returns the path of the file of the program in the OS file system.
+func (p *program) path(file string) string {
+	if p.root == "" || strings.HasPrefix(file, "<") {
+		return file
+	}
+	return filepath.Join(p.root, filepath.FromSlash(file))
+}
+
+type checker struct {
+	errors, warnings int
+}
+
+func (c *checker) errorf(pos string, format string, args ...interface{}) {
+	c.errors++
+	fmt.Printf("%s: error: %s\n", pos, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) warnf(pos string, format string, args ...interface{}) {
+	c.warnings++
+	fmt.Printf("%s: warning: %s\n", pos, fmt.Sprintf(format, args...))
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: glslcheck [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	c := &checker{}
+	for _, dir := range dirs {
+		programs, err := c.find(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		for _, p := range programs {
+			c.check(p)
+		}
+	}
+
+	fmt.Printf("%d error(s), %d warning(s)\n", c.errors, c.warnings)
+	if c.errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// find returns the shader programs under the directory.
+func (c *checker) find(root string) ([]*program, error) {
+	var programs []*program
+	shaderFiles := make(map[string]bool)
+	referenced := make(map[string]bool)
+
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if name := fi.Name(); path != root && (strings.HasPrefix(name, ".") || name == "resources") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".vs", ".fs", ".glsl":
+			shaderFiles[path] = true
+		case ".go":
+			ps, err := c.parseGo(path)
+			if err != nil {
+				return err
+			}
+			for _, p := range ps {
+				for _, file := range p.files {
+					referenced[p.path(file)] = true
+				}
+			}
+			programs = append(programs, ps...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// pair the files not referenced by the Go code by their names.
+	var files []string
+	for file := range shaderFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if referenced[file] {
+			continue
+		}
+		var fragment string
+		switch {
+		case strings.HasSuffix(file, ".vs"):
+			fragment = strings.TrimSuffix(file, ".vs") + ".fs"
+		case filepath.Base(file) == "vertex.glsl":
+			fragment = filepath.Join(filepath.Dir(file), "fragment.glsl")
+		default:
+			continue
+		}
+		if !shaderFiles[fragment] || referenced[fragment] {
+			c.warnf(file, "vertex shader without fragment shader")
+			continue
+		}
+		referenced[file], referenced[fragment] = true, true
+		p := newProgram("")
+		p.files[shader.VertexStage], p.files[shader.FragmentStage] = file, fragment
+		programs = append(programs, p)
+	}
+	for _, file := range files {
+		if !referenced[file] {
+			c.warnf(file, "shader is not used by any program")
+		}
+	}
+
+	return programs, nil
+}
+
+// parseGo finds the shader programs and the uniforms set by name in the Go file.
+func (c *checker) parseGo(file string) ([]*program, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+
+	var programs []*program
+	vars := make(map[string]*program) // the variables the programs are assigned to
+	var uniforms []*ast.CallExpr
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Rhs) != 1 || len(n.Lhs) == 0 {
+				return true
+			}
+			call, ok := n.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			p := parseProgram(call, dir)
+			if p == nil {
+				return true
+			}
+			p.pos = fset.Position(call.Pos()).String()
+			programs = append(programs, p)
+			if id, ok := n.Lhs[0].(*ast.Ident); ok {
+				vars[id.Name] = p
+			}
+		case *ast.CallExpr:
+			switch callName(n) {
+			case "SetUniformName", "SetUniformMatrixName", "SetUniformVecName", "UniformLocation":
+				if len(n.Args) > 0 {
+					uniforms = append(uniforms, n)
+				}
+			}
+		}
+		return true
+	})
+
+	for _, call := range uniforms {
+		name, ok := stringLit(call.Args[0])
+		if !ok {
+			continue
+		}
+		var p *program
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				p = vars[id.Name]
+			}
+		}
+		if p == nil && len(programs) == 1 {
+			p = programs[0]
+		}
+		if p == nil {
+			c.warnf(fset.Position(call.Pos()).String(), "can not find the program of uniform %q", name)
+			continue
+		}
+		p.uniforms[name] = fset.Position(call.Pos()).String()
+	}
+
+	return programs, nil
+}
+
+// parseProgram returns the program created by the call, or nil if it does not create a program,
+// or the files are not string literals.
+func parseProgram(call *ast.CallExpr, dir string) *program {
+	p := newProgram("")
+	switch callName(call) {
+	case "NewShader", "NewShaderFS":
+		args := call.Args
+		if callName(call) == "NewShaderFS" {
+			if len(args) != 3 {
+				return nil
+			}
+			args, p.root = args[1:], dir
+		}
+		if len(args) != 2 {
+			return nil
+		}
+		vs, ok1 := stringLit(args[0])
+		fs, ok2 := stringLit(args[1])
+		if !ok1 || !ok2 {
+			return nil
+		}
+		p.files[shader.VertexStage], p.files[shader.FragmentStage] = vs, fs
+	case "Build":
+		// walk the chain of the builder calls back to shader.NewBuilder().
+		builder := false
+		for c := call; c != nil && !builder; {
+			sel, ok := c.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return nil
+			}
+			switch sel.Sel.Name {
+			case "NewBuilder":
+				builder = true
+			case "FS":
+				p.root = dir
+			case "File", "Source":
+				if len(c.Args) != 2 {
+					return nil
+				}
+				stage, ok1 := stageConst(c.Args[0])
+				s, ok2 := stringLit(c.Args[1])
+				if !ok1 || !ok2 {
+					return nil
+				}
+				if sel.Sel.Name == "File" {
+					p.files[stage] = s
+				} else {
+					p.sources[stage] = s
+				}
+			case "Define":
+				if len(c.Args) != 2 {
+					return nil
+				}
+				name, ok1 := stringLit(c.Args[0])
+				value, ok2 := stringLit(c.Args[1])
+				if !ok1 || !ok2 {
+					return nil
+				}
+				p.defines[name] = value
+			}
+			c, _ = sel.X.(*ast.CallExpr)
+		}
+		if !builder || len(p.files)+len(p.sources) == 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if p.root == "" {
+		for stage, file := range p.files {
+			p.files[stage] = filepath.Join(dir, file)
+		}
+	}
+	return p
+}
+
+// stageConst returns the stage of a shader.XxxStage expression.
+func stageConst(e ast.Expr) (shader.Stage, bool) {
+	var name string
+	switch e := e.(type) {
+	case *ast.SelectorExpr:
+		name = e.Sel.Name
+	case *ast.Ident:
+		name = e.Name
+	}
+	for _, s := range stages {
+		if s.name == name {
+			return s.stage, true
+		}
+	}
+	return 0, false
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	case *ast.Ident:
+		return fun.Name
+	}
+	return ""
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// check validates the program.
+func (c *checker) check(p *program) {
+	pos := p.pos
+	if pos == "" {
+		pos = p.files[shader.VertexStage]
+	}
+
+	pp := &shader.Preprocessor{Defines: p.defines}
+	if p.root != "" {
+		pp.FS = os.DirFS(p.root)
+	}
+	sources := make(map[shader.Stage]*shader.Source)
+	var files []string
+	for _, s := range stages {
+		var src *shader.Source
+		var err error
+		if file, ok := p.files[s.stage]; ok {
+			src, err = pp.ProcessFile(file)
+			files = append(files, p.path(file))
+		} else if code, ok := p.sources[s.stage]; ok {
+			src, err = pp.ProcessSource(code)
+		} else {
+			continue
+		}
+		if err != nil {
+			c.errorf(pos, "%v", err)
+			return
+		}
+		sources[s.stage] = src
+	}
+
+	for _, d := range shader.CheckInterfaces(sources) {
+		loc := shader.Location{File: p.path(d.File), Line: d.Line}
+		if d.Severity == shader.SeverityError {
+			c.errorf(loc.String(), "%s", d.Message)
+		} else {
+			c.warnf(loc.String(), "%s", d.Message)
+		}
+	}
+
+	var interfaces []*shader.Interface
+	for _, s := range stages {
+		if src, ok := sources[s.stage]; ok {
+			interfaces = append(interfaces, shader.ParseInterface(src.Code))
+		}
+	}
+	if src, ok := sources[shader.FragmentStage]; ok && len(shader.ParseInterface(src.Code).Outputs) == 0 {
+		c.errorf(p.path(src.Name), "fragment shader has no output")
+	}
+
+	var names []string
+	for name := range p.uniforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+next:
+	for _, name := range names {
+		for _, it := range interfaces {
+			if _, ok := it.Uniform(name); ok {
+				continue next
+			}
+		}
+		c.errorf(p.uniforms[name], "uniform %q is not declared in %s", name, strings.Join(files, " or "))
+	}
+}
@@ -0,0 +1,27 @@
+// Package glext queries the version and the extensions of the current GL context,
+// it is shared by the shader and texture packages to check the optional features.
+package glext
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Version returns the GL version of the current context as major*10+minor, e.g. 42 for 4.2.
+func Version() int {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	return int(major*10 + minor)
+}
+
+// HasExtension reports whether the GL extension is supported by the current context.
+func HasExtension(name string) bool {
+	var n int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/ginuerzh/learnopengl/utils/std140"
+)
+
+// SetUniforms sets the uniforms from the fields of the struct v (or a pointer to it).
+// The uniform of a field is named by the glsl tag, or the field name if there is no tag,
+// the fields tagged with `glsl:"-"` and the unexported fields are skipped.
+//
+//	type Material struct {
+//		Diffuse   int32   `glsl:"diffuse"`
+//		Shininess float32 `glsl:"shininess"`
+//	}
+//
+//	err := shader.SetUniforms(struct {
+//		Model    mgl32.Mat4   `glsl:"model"`
+//		Lights   []mgl32.Vec3 `glsl:"lights"`
+//		Material Material     `glsl:"material"`
+//	}{model, lights, material})
+//
+// The field types are dispatched to SetUniform, SetUniformVec and SetUniformMatrix,
+// bool and int fields are set as int32, slices and arrays are set as GLSL arrays,
+// nested structs are set as GLSL struct members like "material.diffuse",
+// and slices or arrays of structs as "lights[0].position".
+// All the fields are set even if some of them fail,
+// the returned error is a UniformsError listing every failed field.
+func (s *Shader) SetUniforms(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported type %T, must be a struct", v)
+	}
+
+	var errs UniformsError
+	s.setStruct(rv, "", "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *Shader) setStruct(v reflect.Value, prefix, path string, errs *UniformsError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := std140.FieldName(f)
+		if !ok {
+			continue
+		}
+		s.setField(v.Field(i), prefix+name, path+f.Name, errs)
+	}
+}
+
+func (s *Shader) setField(v reflect.Value, name, path string, errs *UniformsError) {
+	switch {
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		s.setField(v.Elem(), name, path, errs)
+		return
+	case v.Kind() == reflect.Struct:
+		s.setStruct(v, name+".", path+".", errs)
+		return
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Struct:
+		for i := 0; i < v.Len(); i++ {
+			s.setStruct(v.Index(i), fmt.Sprintf("%s[%d].", name, i), fmt.Sprintf("%s[%d].", path, i), errs)
+		}
+		return
+	}
+
+	err := func() error {
+		value, err := uniformValue(v)
+		if err != nil {
+			return err
+		}
+		location, err := s.UniformLocation(name)
+		if err != nil {
+			return err
+		}
+		if isMatrix(v.Type()) {
+			return s.SetUniformMatrix(location, false, value)
+		}
+		return s.SetUniform(location, value)
+	}()
+	if err != nil {
+		*errs = append(*errs, &FieldError{Field: path, Uniform: name, Err: err})
+	}
+}
+
+func isMathgl(t reflect.Type) bool {
+	return strings.HasPrefix(t.PkgPath(), "github.com/go-gl/mathgl/")
+}
+
+func isMatrix(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if !isMathgl(t) {
+			t = t.Elem()
+		}
+	}
+	return isMathgl(t) && strings.HasPrefix(t.Name(), "Mat")
+}
+
+// uniformValue converts the field value to a type accepted by SetUniform or SetUniformMatrix.
+func uniformValue(v reflect.Value) (interface{}, error) {
+	t := v.Type()
+	if isMathgl(t) {
+		return v.Interface(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return int32(1), nil
+		}
+		return int32(0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if err := checkRange(v); err != nil {
+			return nil, err
+		}
+		return int32(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if err := checkRange(v); err != nil {
+			return nil, err
+		}
+		return uint32(v.Uint()), nil
+	case reflect.Float32:
+		return float32(v.Float()), nil
+	case reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		return arrayValue(v)
+	}
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
+
+// arrayValue converts the slice or array to a slice accepted by SetUniformVec or SetUniformMatrix.
+func arrayValue(v reflect.Value) (interface{}, error) {
+	if v.Len() == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	elem := v.Type().Elem()
+	var st reflect.Type
+	switch {
+	case isMathgl(elem):
+		st = reflect.SliceOf(elem)
+	case elem.Kind() == reflect.Float32, elem.Kind() == reflect.Float64:
+		st = reflect.SliceOf(reflect.TypeOf(float32(0)))
+		if elem.Kind() == reflect.Float64 {
+			st = reflect.SliceOf(reflect.TypeOf(float64(0)))
+		}
+	case elem.Kind() == reflect.Bool,
+		elem.Kind() >= reflect.Int && elem.Kind() <= reflect.Int64:
+		st = reflect.SliceOf(reflect.TypeOf(int32(0)))
+	case elem.Kind() >= reflect.Uint && elem.Kind() <= reflect.Uint64:
+		st = reflect.SliceOf(reflect.TypeOf(uint32(0)))
+	default:
+		return nil, fmt.Errorf("unsupported type %v", v.Type())
+	}
+
+	s := reflect.MakeSlice(st, v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		switch {
+		case isMathgl(elem):
+			s.Index(i).Set(e)
+		case elem.Kind() == reflect.Bool:
+			if e.Bool() {
+				s.Index(i).SetInt(1)
+			}
+		case elem.Kind() >= reflect.Int && elem.Kind() <= reflect.Int64:
+			if err := checkRange(e); err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			s.Index(i).SetInt(e.Int())
+		case elem.Kind() >= reflect.Uint && elem.Kind() <= reflect.Uint64:
+			if err := checkRange(e); err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			s.Index(i).SetUint(e.Uint())
+		default:
+			s.Index(i).SetFloat(e.Float())
+		}
+	}
+	return s.Interface(), nil
+}
+
+// checkRange checks the integer value fits in the 32-bit int or uint of GLSL.
+func checkRange(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		if n := v.Int(); n < math.MinInt32 || n > math.MaxInt32 {
+			return fmt.Errorf("value %d overflows int32", n)
+		}
+	case reflect.Uint, reflect.Uint64:
+		if n := v.Uint(); n > math.MaxUint32 {
+			return fmt.Errorf("value %d overflows uint32", n)
+		}
+	}
+	return nil
+}
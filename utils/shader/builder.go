@@ -0,0 +1,271 @@
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Stage is a programmable stage of the pipeline, its value is the GL shader type.
+type Stage uint32
+
+const (
+	VertexStage         Stage = gl.VERTEX_SHADER
+	TessControlStage    Stage = gl.TESS_CONTROL_SHADER
+	TessEvaluationStage Stage = gl.TESS_EVALUATION_SHADER
+	GeometryStage       Stage = gl.GEOMETRY_SHADER
+	FragmentStage       Stage = gl.FRAGMENT_SHADER
+	ComputeStage        Stage = gl.COMPUTE_SHADER
+)
+
+// stageOrder is the order of the stages in the pipeline,
+// the shaders are compiled and attached in this order.
+var stageOrder = []Stage{
+	VertexStage,
+	TessControlStage,
+	TessEvaluationStage,
+	GeometryStage,
+	FragmentStage,
+	ComputeStage,
+}
+
+func (stage Stage) String() string {
+	switch stage {
+	case VertexStage:
+		return "vertex"
+	case TessControlStage:
+		return "tessellation control"
+	case TessEvaluationStage:
+		return "tessellation evaluation"
+	case GeometryStage:
+		return "geometry"
+	case FragmentStage:
+		return "fragment"
+	case ComputeStage:
+		return "compute"
+	}
+	return fmt.Sprintf("stage(0x%x)", uint32(stage))
+}
+
+func (stage Stage) valid() bool {
+	for _, s := range stageOrder {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Builder collects the stages of a shader program.
+// A stage can be given either as a file or as source code,
+// the last one set for a stage wins.
+//
+//	shader, err := shader.NewBuilder().
+//		File(shader.VertexStage, "9.1.geometry_shader.vs").
+//		File(shader.GeometryStage, "9.1.geometry_shader.gs").
+//		File(shader.FragmentStage, "9.1.geometry_shader.fs").
+//		Build()
+type Builder struct {
+	files   map[Stage]string
+	sources map[Stage]string
+	defines map[string]string
+	cache   *ProgramCache
+	fsys    fs.FS
+	target  Profile
+}
+
+// NewBuilder creates an empty shader program builder.
+func NewBuilder() *Builder {
+	return &Builder{
+		files:   make(map[Stage]string),
+		sources: make(map[Stage]string),
+		defines: make(map[string]string),
+	}
+}
+
+// Define injects a "#define name value" directive into every stage.
+func (b *Builder) Define(name, value string) *Builder {
+	b.defines[name] = value
+	return b
+}
+
+// FS sets the file system the shader files and the included files are read from,
+// e.g. an embed.FS to compile the shaders into the binary. nil means the OS file system.
+func (b *Builder) FS(fsys fs.FS) *Builder {
+	b.fsys = fsys
+	return b
+}
+
+// File sets the shader file of the stage.
+func (b *Builder) File(stage Stage, file string) *Builder {
+	delete(b.sources, stage)
+	b.files[stage] = file
+	return b
+}
+
+// Source sets the shader source code of the stage.
+func (b *Builder) Source(stage Stage, source string) *Builder {
+	delete(b.files, stage)
+	b.sources[stage] = source
+	return b
+}
+
+// Stages returns the stages attached to the builder in pipeline order.
+func (b *Builder) Stages() []Stage {
+	var stages []Stage
+	for _, stage := range stageOrder {
+		if b.has(stage) {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+func (b *Builder) has(stage Stage) bool {
+	_, file := b.files[stage]
+	_, source := b.sources[stage]
+	return file || source
+}
+
+// Validate checks that the attached stages form a legal program:
+// a compute shader must be alone, otherwise a vertex shader is required,
+// and a tessellation control shader requires a tessellation evaluation shader.
+// ProfileGLES30 supports only a vertex and a fragment shader.
+func (b *Builder) Validate() error {
+	for stage := range b.files {
+		if !stage.valid() {
+			return fmt.Errorf("unknown shader %v", stage)
+		}
+	}
+	for stage := range b.sources {
+		if !stage.valid() {
+			return fmt.Errorf("unknown shader %v", stage)
+		}
+	}
+
+	stages := b.Stages()
+	if len(stages) == 0 {
+		return errors.New("no shader stage")
+	}
+	if b.has(ComputeStage) {
+		if b.target == ProfileGLES30 {
+			return fmt.Errorf("compute shader is not supported by %v", b.target)
+		}
+		if len(stages) > 1 {
+			return errors.New("compute shader can not be linked with other stages")
+		}
+		return nil
+	}
+	if !b.has(VertexStage) {
+		return errors.New("missing vertex shader")
+	}
+	if b.has(TessControlStage) && !b.has(TessEvaluationStage) {
+		return errors.New("tessellation control shader requires a tessellation evaluation shader")
+	}
+	if b.target == ProfileGLES30 {
+		if len(stages) != 2 || !b.has(FragmentStage) {
+			return fmt.Errorf("%v requires exactly a vertex and a fragment shader", b.target)
+		}
+	}
+	return nil
+}
+
+// Build reads the shader files, then compiles and links all the stages into a program.
+func (b *Builder) Build() (*Shader, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	sources, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := b.cache
+	if cache != nil && !programBinarySupported() {
+		cache = nil
+	}
+
+	var key string
+	var program uint32
+	var warnings []Diagnostic
+	if cache != nil {
+		key = cache.key(sources)
+		if program = cache.load(key); program != 0 {
+			warnings = CheckInterfaces(sources)
+		}
+	}
+	if program == 0 {
+		program, warnings, err = newPragram(sources, cache != nil)
+		if err != nil {
+			return nil, err
+		}
+		if cache != nil {
+			// the cache is only an optimization, the program is usable even if it fails to be stored.
+			cache.store(key, program)
+		}
+	}
+
+	shader := &Shader{
+		ID:         program,
+		builder:    b.clone(),
+		reflection: reflectProgram(program),
+		warnings:   warnings,
+	}
+	for stage, source := range sources {
+		shader.setSource(stage, source.Code)
+		for _, file := range source.Files {
+			shader.addFile(file)
+		}
+	}
+	track(shader)
+
+	return shader, nil
+}
+
+func (b *Builder) clone() *Builder {
+	c := NewBuilder()
+	for stage, file := range b.files {
+		c.files[stage] = file
+	}
+	for stage, source := range b.sources {
+		c.sources[stage] = source
+	}
+	for name, value := range b.defines {
+		c.defines[name] = value
+	}
+	c.cache = b.cache
+	c.fsys = b.fsys
+	c.target = b.target
+	return c
+}
+
+// load returns the preprocessed source of every attached stage, translated for the target profile.
+func (b *Builder) load() (map[Stage]*Source, error) {
+	p := &Preprocessor{Defines: b.defines, FS: b.fsys}
+	sources := make(map[Stage]*Source)
+	for stage, source := range b.sources {
+		src, err := p.ProcessSource(source)
+		if err != nil {
+			return nil, err
+		}
+		sources[stage] = src
+	}
+	for stage, file := range b.files {
+		src, err := p.ProcessFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[stage] = src
+	}
+	for stage, src := range sources {
+		src, err := Translate(src, stage, b.target)
+		if err != nil {
+			return nil, err
+		}
+		sources[stage] = src
+	}
+	return sources, nil
+}
@@ -0,0 +1,196 @@
+package shader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ginuerzh/learnopengl/utils/glext"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// cacheMagic is the file header of the cache entries.
+var cacheMagic = []byte("GLPB")
+
+const cacheExt = ".glpb"
+
+// ProgramCache is an on-disk cache of linked program binaries.
+// The entries are keyed by a hash of the sources of all stages and the GL vendor, renderer and version,
+// so a driver update never loads a stale binary.
+// The cache is used only if the driver supports GL_ARB_get_program_binary,
+// a binary rejected by the driver is removed and the program is compiled from source transparently.
+type ProgramCache struct {
+	Dir string // the cache directory
+}
+
+// NewProgramCache creates a program cache in the directory, the directory is created if not exists.
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ProgramCache{Dir: dir}, nil
+}
+
+// Cache sets the program cache used by Build.
+func (b *Builder) Cache(cache *ProgramCache) *Builder {
+	b.cache = cache
+	return b
+}
+
+// Purge removes the stale entries: the entries created by another driver,
+// and the entries not used for longer than maxAge if maxAge is positive.
+// It must be called with a current GL context.
+func (c *ProgramCache) Purge(maxAge time.Duration) error {
+	driver := driverHash()
+	return c.walk(func(path string, fi os.FileInfo) error {
+		stale := maxAge > 0 && time.Since(fi.ModTime()) > maxAge
+		if !stale {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			header := make([]byte, len(cacheMagic)+len(driver))
+			_, err = f.Read(header)
+			f.Close()
+			stale = err != nil || !bytes.Equal(header, append(cacheMagic, driver...))
+		}
+		if stale {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// Clear removes all the entries.
+func (c *ProgramCache) Clear() error {
+	return c.walk(func(path string, fi os.FileInfo) error {
+		return os.Remove(path)
+	})
+}
+
+func (c *ProgramCache) walk(fn func(path string, fi os.FileInfo) error) error {
+	fis, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), cacheExt) {
+			continue
+		}
+		if err := fn(filepath.Join(c.Dir, fi.Name()), fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ProgramCache) path(key string) string {
+	return filepath.Join(c.Dir, key+cacheExt)
+}
+
+// key returns the cache key of the sources.
+func (c *ProgramCache) key(sources map[Stage]*Source) string {
+	h := sha256.New()
+	h.Write(driverHash())
+	for _, stage := range stageOrder {
+		src, ok := sources[stage]
+		if !ok {
+			continue
+		}
+		binary.Write(h, binary.LittleEndian, uint32(stage))
+		binary.Write(h, binary.LittleEndian, uint64(len(src.Code)))
+		h.Write([]byte(src.Code))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// load creates a program from the cached binary,
+// it returns 0 if there is no entry or the driver rejects the binary.
+func (c *ProgramCache) load(key string) uint32 {
+	path := c.path(key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	driver := driverHash()
+	n := len(cacheMagic) + len(driver)
+	if len(data) <= n+4 || !bytes.Equal(data[:n], append(cacheMagic, driver...)) {
+		os.Remove(path)
+		return 0
+	}
+	format := binary.LittleEndian.Uint32(data[n:])
+	data = data[n+4:]
+
+	program := gl.CreateProgram()
+	gl.ProgramBinary(program, format, gl.Ptr(data), int32(len(data)))
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		gl.DeleteProgram(program)
+		os.Remove(path)
+		return 0
+	}
+
+	// keep the used entries from being purged.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return program
+}
+
+// store saves the binary of the linked program.
+func (c *ProgramCache) store(key string, program uint32) error {
+	var length int32
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length <= 0 {
+		return errors.New("empty program binary")
+	}
+
+	var format uint32
+	data := make([]byte, length)
+	gl.GetProgramBinary(program, length, &length, &format, gl.Ptr(data))
+
+	var buf bytes.Buffer
+	buf.Write(cacheMagic)
+	buf.Write(driverHash())
+	binary.Write(&buf, binary.LittleEndian, format)
+	buf.Write(data[:length])
+
+	// write to a temporary file first, so a concurrent reader never sees a partial entry.
+	path := c.path(key)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// driverHash returns the hash of the GL vendor, renderer and version strings.
+func driverHash() []byte {
+	h := sha256.New()
+	for _, name := range []uint32{gl.VENDOR, gl.RENDERER, gl.VERSION} {
+		h.Write([]byte(gl.GoStr(gl.GetString(name))))
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
+
+// programBinarySupported reports whether the driver can save and load program binaries,
+// which is core since GL 4.1.
+func programBinarySupported() bool {
+	if glext.Version() < 41 && !glext.HasExtension("GL_ARB_get_program_binary") {
+		return false
+	}
+	var formats int32
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+	return formats > 0
+}
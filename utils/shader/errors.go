@@ -0,0 +1,184 @@
+package shader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a diagnostic message.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+func parseSeverity(s string) Severity {
+	switch strings.ToLower(s) {
+	case "warning":
+		return SeverityWarning
+	case "info", "note":
+		return SeverityInfo
+	}
+	return SeverityError
+}
+
+// Diagnostic is a single message of a compile or link log.
+type Diagnostic struct {
+	File     string // the original file, empty if the driver does not report the location
+	Line     int    // 1-based line number in File, 0 if unknown
+	Column   int    // 1-based column number, 0 if unknown
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line <= 0 {
+		return fmt.Sprintf("%v: %s", d.Severity, d.Message)
+	}
+	if d.Column <= 0 {
+		return fmt.Sprintf("%s:%d: %v: %s", d.File, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// CompileError is returned when a shader stage fails to compile.
+type CompileError struct {
+	Stage       Stage
+	File        string       // the shader file, or "<source>" if the stage is given as source code
+	Log         string       // the info log of the driver
+	Diagnostics []Diagnostic // the messages parsed from Log
+}
+
+func (e *CompileError) Error() string {
+	return formatError(fmt.Sprintf("failed to compile %v shader %s", e.Stage, e.File), e.Log, e.Diagnostics)
+}
+
+// LinkError is returned when a program fails to link.
+type LinkError struct {
+	Log         string       // the info log of the driver
+	Diagnostics []Diagnostic // the messages parsed from Log
+	Warnings    []Diagnostic // the problems found in the interfaces between the stages
+}
+
+func (e *LinkError) Error() string {
+	return formatError("failed to link program", e.Log, append(e.Diagnostics[:len(e.Diagnostics):len(e.Diagnostics)], e.Warnings...))
+}
+
+func formatError(head, log string, diags []Diagnostic) string {
+	var b strings.Builder
+	b.WriteString(head)
+	if len(diags) == 0 {
+		if log = strings.TrimSpace(log); log != "" {
+			b.WriteString(": ")
+			b.WriteString(log)
+		}
+		return b.String()
+	}
+	for _, d := range diags {
+		b.WriteString("\n\t")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// the log formats of the common drivers.
+var (
+	// Mesa: 0:12(5): error: message
+	mesaLogRe = regexp.MustCompile(`^(\d+):(\d+)\((\d+)\):\s*(error|warning|info)\s*:?\s*(.*)$`)
+	// NVIDIA: 0(12) : error C0000: message
+	nvidiaLogRe = regexp.MustCompile(`^(\d+)\((\d+)\)\s*:\s*(error|warning|info)\s*(?:[A-Z]\d+)?\s*:\s*(.*)$`)
+	// AMD, Intel, Apple: ERROR: 0:12: message
+	amdLogRe = regexp.MustCompile(`^(ERROR|WARNING|INFO):\s*(\d+):(\d+):\s*(.*)$`)
+	// messages without location, mostly link logs: error: message
+	plainLogRe = regexp.MustCompile(`(?i)^(error|warning|info|note)\s*(?:[A-Z]\d+)?\s*:\s*(.*)$`)
+	// AMD summary line: ERROR: 1 compilation errors.  No code generated.
+	summaryLogRe = regexp.MustCompile(`(?i)^error:\s*\d+ compilation errors?\.`)
+)
+
+// ParseLog parses the compile or link log in the formats of Mesa, NVIDIA and AMD/Intel drivers.
+// The line numbers are mapped back to the original files through src if it is not nil.
+// The lines that do not match any known format are kept as error messages without location.
+func ParseLog(log string, src *Source) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range strings.Split(strings.TrimRight(log, "\x00"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || summaryLogRe.MatchString(line) {
+			continue
+		}
+
+		var d Diagnostic
+		if m := mesaLogRe.FindStringSubmatch(line); m != nil {
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			d.Severity = parseSeverity(m[4])
+			d.Message = m[5]
+		} else if m := nvidiaLogRe.FindStringSubmatch(line); m != nil {
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Severity = parseSeverity(m[3])
+			d.Message = m[4]
+		} else if m := amdLogRe.FindStringSubmatch(line); m != nil {
+			d.Line, _ = strconv.Atoi(m[3])
+			d.Severity = parseSeverity(m[1])
+			d.Message = m[4]
+		} else if m := plainLogRe.FindStringSubmatch(line); m != nil {
+			d.Severity = parseSeverity(m[1])
+			d.Message = m[2]
+		} else {
+			d.Message = line
+		}
+
+		if d.Line > 0 {
+			if loc, ok := src.Locate(d.Line); ok {
+				d.File, d.Line = loc.File, loc.Line
+			} else if src != nil {
+				d.File = src.Name
+			}
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// FieldError is an error of setting a uniform from a struct field.
+type FieldError struct {
+	Field   string // the Go field path, e.g. "Material.Diffuse"
+	Uniform string // the uniform name, e.g. "material.diffuse"
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Field, e.Uniform, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// UniformsError is the aggregated error of setting uniforms from a struct.
+type UniformsError []*FieldError
+
+func (e UniformsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to set %d uniform(s)", len(e))
+	for _, fe := range e {
+		b.WriteString("\n\t")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
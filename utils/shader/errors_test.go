@@ -0,0 +1,74 @@
+package shader
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseLog(t *testing.T) {
+	p := &Preprocessor{FS: fstest.MapFS{
+		"main.frag":      {Data: []byte("#version 330 core\n#include \"lib/light.glsl\"\nout vec4 color;\nvoid main() {}\n")},
+		"lib/light.glsl": {Data: []byte("uniform vec3 lightPos;\nvec3 light() { return foo; }\nfloat bar;\n")},
+	}}
+	src, err := p.ProcessFile("main.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		log  string
+		want []Diagnostic
+	}{
+		{
+			name: "mesa",
+			log:  "0:3(23): error: `foo' undeclared\n0:1(10): warning: extension `GL_ARB_foo' unsupported\n",
+			want: []Diagnostic{
+				{File: "lib/light.glsl", Line: 2, Column: 23, Severity: SeverityError, Message: "`foo' undeclared"},
+				{File: "main.frag", Line: 1, Column: 10, Severity: SeverityWarning, Message: "extension `GL_ARB_foo' unsupported"},
+			},
+		},
+		{
+			name: "nvidia",
+			log:  "0(5) : error C0000: syntax error, unexpected '}' at token \"}\"\n0(2) : warning C7022: unrecognized profile specifier \"core\"\n",
+			want: []Diagnostic{
+				{File: "main.frag", Line: 3, Severity: SeverityError, Message: "syntax error, unexpected '}' at token \"}\""},
+				{File: "lib/light.glsl", Line: 1, Severity: SeverityWarning, Message: "unrecognized profile specifier \"core\""},
+			},
+		},
+		{
+			name: "amd",
+			log:  "ERROR: 0:4: 'bar' : redefinition\nERROR: 1 compilation errors.  No code generated.\n\x00",
+			want: []Diagnostic{
+				{File: "lib/light.glsl", Line: 3, Severity: SeverityError, Message: "'bar' : redefinition"},
+			},
+		},
+		{
+			name: "link",
+			log:  "error: vertex shader output `normal' not read by fragment shader\n",
+			want: []Diagnostic{
+				{Severity: SeverityError, Message: "vertex shader output `normal' not read by fragment shader"},
+			},
+		},
+		{
+			name: "out of source",
+			log:  "0:42(1): error: unexpected end of file\n",
+			want: []Diagnostic{
+				{File: "main.frag", Line: 42, Column: 1, Severity: SeverityError, Message: "unexpected end of file"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseLog(tt.log, src)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d diagnostics %v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("diagnostic %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package shader
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Declaration is a global in, out or uniform variable declared in a GLSL source.
+type Declaration struct {
+	Storage  string // "in", "out" or "uniform"
+	Type     string // the GLSL type, e.g. "vec3", or the struct name
+	Name     string
+	Array    string // the array size, e.g. "64", empty if the variable is not an array
+	Location int    // the location of layout(location = N), -1 if not specified
+	Line     int    // 1-based line number in the source
+	// Interpolation is the interpolation qualifier: "flat", "smooth" or "noperspective", empty if not specified.
+	Interpolation string
+}
+
+// TypeString returns the type including the array size, e.g. "mat4[64]".
+func (d Declaration) TypeString() string {
+	if d.Array != "" {
+		return d.Type + "[" + d.Array + "]"
+	}
+	return d.Type
+}
+
+// Interface is the global interface of a GLSL source: its inputs, outputs and uniforms.
+// The uniform blocks are listed as uniforms with the block name as their type and name.
+type Interface struct {
+	Inputs   []Declaration
+	Outputs  []Declaration
+	Uniforms []Declaration
+	Structs  map[string][]Declaration // the members of the structs by struct name
+}
+
+// Input returns the input variable by name.
+func (it *Interface) Input(name string) (Declaration, bool) {
+	return findDeclaration(it.Inputs, name)
+}
+
+// Output returns the output variable by name.
+func (it *Interface) Output(name string) (Declaration, bool) {
+	return findDeclaration(it.Outputs, name)
+}
+
+// Uniform returns the uniform variable by name, the name can also refer to
+// an array element like "bones[3]" or a struct member like "material.diffuse".
+func (it *Interface) Uniform(name string) (Declaration, bool) {
+	base := name
+	if i := strings.IndexAny(base, ".["); i >= 0 {
+		base = base[:i]
+	}
+	d, ok := findDeclaration(it.Uniforms, base)
+	if !ok {
+		return d, false
+	}
+
+	// resolve the struct members.
+	rest := name[len(base):]
+	for rest != "" {
+		if rest[0] == '[' {
+			i := strings.IndexByte(rest, ']')
+			if i < 0 {
+				return d, false
+			}
+			rest = rest[i+1:]
+			continue
+		}
+		rest = rest[1:]
+		member := rest
+		if i := strings.IndexAny(member, ".["); i >= 0 {
+			member = member[:i]
+		}
+		if d, ok = findDeclaration(it.Structs[d.Type], member); !ok {
+			return d, false
+		}
+		rest = rest[len(member):]
+	}
+	return d, true
+}
+
+func findDeclaration(decls []Declaration, name string) (Declaration, bool) {
+	for _, d := range decls {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Declaration{}, false
+}
+
+var (
+	layoutRe     = regexp.MustCompile(`^layout\s*\(([^)]*)\)\s*`)
+	locationRe   = regexp.MustCompile(`\blocation\s*=\s*(\d+)`)
+	qualifierRe  = regexp.MustCompile(`^(flat|smooth|noperspective|centroid|sample|invariant|precise|patch|highp|mediump|lowp)\s+`)
+	declaratorRe = regexp.MustCompile(`^(\w+)\s*(?:\[\s*([^\]]*)\s*\])?`)
+)
+
+// ParseInterface parses the global in, out and uniform declarations and the struct definitions of the GLSL source.
+// It is not a full GLSL parser, the preprocessor directives are ignored, so the source should have been preprocessed.
+func ParseInterface(code string) *Interface {
+	it := &Interface{Structs: make(map[string][]Declaration)}
+	code = stripComments(code)
+
+	depth := 0
+	start, startLine, line := 0, 1, 1
+	var block string  // the statement before the opening brace at depth 0
+	var blockLine int // the line of block
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '\n':
+			line++
+			// the preprocessor directives end at the end of line.
+			if depth == 0 && strings.HasPrefix(strings.TrimSpace(code[start:i]), "#") {
+				start, startLine = i+1, line
+			}
+		case '{':
+			if depth == 0 {
+				block = code[start:i]
+				blockLine = startLine + leadingLines(block)
+				start = i + 1
+			}
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				body := code[start:i]
+				if strings.Contains(block, "(") && !strings.HasPrefix(strings.TrimSpace(block), "layout") {
+					// function definition
+					block = ""
+					start, startLine = i+1, line
+					continue
+				}
+				// struct or block definition, the declarators follow the closing brace.
+				j := strings.IndexByte(code[i:], ';')
+				if j < 0 {
+					j = len(code) - i
+				}
+				it.definition(block, body, code[i+1:i+j], blockLine)
+				line += strings.Count(code[i:i+j], "\n")
+				i += j
+				block = ""
+				start, startLine = i+1, line
+			}
+		case ';':
+			if depth == 0 {
+				it.statement(code[start:i], startLine+leadingLines(code[start:i]))
+				start, startLine = i+1, line
+			}
+		}
+	}
+	return it
+}
+
+// statement parses a global declaration statement.
+func (it *Interface) statement(stmt string, line int) {
+	stmt = strings.TrimSpace(stmt)
+	location := -1
+	if m := layoutRe.FindStringSubmatch(stmt); m != nil {
+		if lm := locationRe.FindStringSubmatch(m[1]); lm != nil {
+			location, _ = strconv.Atoi(lm[1])
+		}
+		stmt = stmt[len(m[0]):]
+	}
+	interp := ""
+	for {
+		m := qualifierRe.FindString(stmt)
+		if m == "" {
+			break
+		}
+		if q := strings.TrimSpace(m); isInterpolation(q) {
+			interp = q
+		}
+		stmt = stmt[len(m):]
+	}
+
+	fields := strings.Fields(stmt)
+	if len(fields) < 3 {
+		return
+	}
+	storage := fields[0]
+	if storage != "in" && storage != "out" && storage != "uniform" {
+		return
+	}
+	// the precision qualifiers may follow the storage qualifier.
+	for len(fields) > 3 && qualifierRe.MatchString(fields[1]+" ") {
+		if isInterpolation(fields[1]) {
+			interp = fields[1]
+		}
+		fields = append(fields[:1], fields[2:]...)
+	}
+	typ := fields[1]
+	decls := parseDeclarators(strings.Join(fields[2:], " "), storage, typ, location, line)
+	for i := range decls {
+		decls[i].Interpolation = interp
+	}
+	it.add(decls)
+}
+
+func isInterpolation(q string) bool {
+	return q == "flat" || q == "smooth" || q == "noperspective"
+}
+
+// definition parses a struct definition or an interface block.
+func (it *Interface) definition(head, body, declarators string, line int) {
+	head = strings.TrimSpace(layoutRe.ReplaceAllString(strings.TrimSpace(head), ""))
+	fields := strings.Fields(head)
+	if len(fields) < 2 {
+		return
+	}
+
+	var members []Declaration
+	for _, stmt := range strings.Split(body, ";") {
+		f := strings.Fields(stmt)
+		interp := ""
+		for len(f) > 0 && qualifierRe.MatchString(f[0]+" ") {
+			if isInterpolation(f[0]) {
+				interp = f[0]
+			}
+			f = f[1:]
+		}
+		if len(f) < 2 {
+			continue
+		}
+		decls := parseDeclarators(strings.Join(f[1:], " "), "", f[0], -1, line)
+		for i := range decls {
+			decls[i].Interpolation = interp
+		}
+		members = append(members, decls...)
+	}
+
+	// struct Light { ... } light; or uniform struct Light { ... } light;
+	if fields[0] == "struct" || (len(fields) > 2 && fields[1] == "struct") {
+		storage := ""
+		if fields[0] != "struct" {
+			storage, fields = fields[0], fields[1:]
+		}
+		it.Structs[fields[1]] = members
+		if storage != "" {
+			it.add(parseDeclarators(declarators, storage, fields[1], -1, line))
+		}
+		return
+	}
+
+	storage, name := fields[0], fields[1]
+	it.Structs[name] = members
+	decls := parseDeclarators(declarators, storage, name, -1, line)
+	if len(decls) == 0 {
+		// the members of a block without instance name are global variables.
+		for i := range members {
+			members[i].Storage = storage
+		}
+		decls = members
+	}
+	it.add(decls)
+}
+
+// add appends the declarations to the list of their storage.
+func (it *Interface) add(decls []Declaration) {
+	for _, d := range decls {
+		switch d.Storage {
+		case "in":
+			it.Inputs = append(it.Inputs, d)
+		case "out":
+			it.Outputs = append(it.Outputs, d)
+		case "uniform":
+			it.Uniforms = append(it.Uniforms, d)
+		}
+	}
+}
+
+// parseDeclarators parses the comma separated declarators like "a, b[2] = ...".
+func parseDeclarators(s, storage, typ string, location, line int) []Declaration {
+	var decls []Declaration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			part = strings.TrimSpace(part[:i])
+		}
+		m := declaratorRe.FindStringSubmatch(part)
+		if m == nil {
+			continue
+		}
+		decls = append(decls, Declaration{
+			Storage:  storage,
+			Type:     typ,
+			Name:     m[1],
+			Array:    strings.TrimSpace(m[2]),
+			Location: location,
+			Line:     line,
+		})
+		if location >= 0 {
+			location++
+		}
+	}
+	return decls
+}
+
+// stripComments replaces the comments with spaces, the newlines are kept so the line numbers do not change.
+func stripComments(code string) string {
+	b := []byte(code)
+	for i := 0; i < len(b)-1; i++ {
+		if b[i] == '/' && b[i+1] == '/' {
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		} else if b[i] == '/' && b[i+1] == '*' {
+			for ; i < len(b); i++ {
+				if b[i] == '*' && i+1 < len(b) && b[i+1] == '/' {
+					b[i], b[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if b[i] != '\n' {
+					b[i] = ' '
+				}
+			}
+		}
+	}
+	return string(b)
+}
+
+// leadingLines returns the number of the newlines before the first non-space character.
+func leadingLines(s string) int {
+	n := 0
+	for _, c := range s {
+		if c == '\n' {
+			n++
+		} else if c != ' ' && c != '\t' && c != '\r' {
+			break
+		}
+	}
+	return n
+}
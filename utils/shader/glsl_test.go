@@ -0,0 +1,96 @@
+package shader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInterface(t *testing.T) {
+	code := `#version 330 core
+layout (location = 0) in vec3 aPos;
+layout(location=1) in vec2 aUV, aUV2;
+flat out int id; // the instance id
+smooth centroid out vec3 normal;
+out highp vec4 color;
+noperspective out float depth;
+/* out vec3 commented;
+*/
+uniform mat4 bones[64];
+struct Light {
+	vec3 position;
+	float radius;
+};
+uniform Light lights[4];
+out VS_OUT {
+	vec3 normal;
+	flat int layer;
+} vs_out;
+layout (std140) uniform Matrices {
+	mat4 view;
+	mat4 projection;
+};
+void main() {
+	vec3 local;
+	gl_Position = vec4(aPos, 1.0);
+}
+`
+	it := ParseInterface(code)
+
+	inputs := []Declaration{
+		{Storage: "in", Type: "vec3", Name: "aPos", Location: 0, Line: 2},
+		{Storage: "in", Type: "vec2", Name: "aUV", Location: 1, Line: 3},
+		{Storage: "in", Type: "vec2", Name: "aUV2", Location: 2, Line: 3},
+	}
+	outputs := []Declaration{
+		{Storage: "out", Type: "int", Name: "id", Location: -1, Line: 4, Interpolation: "flat"},
+		{Storage: "out", Type: "vec3", Name: "normal", Location: -1, Line: 5, Interpolation: "smooth"},
+		{Storage: "out", Type: "vec4", Name: "color", Location: -1, Line: 6},
+		{Storage: "out", Type: "float", Name: "depth", Location: -1, Line: 7, Interpolation: "noperspective"},
+		{Storage: "out", Type: "VS_OUT", Name: "vs_out", Location: -1, Line: 16},
+	}
+	uniforms := []Declaration{
+		{Storage: "uniform", Type: "mat4", Name: "bones", Array: "64", Location: -1, Line: 10},
+		{Storage: "uniform", Type: "Light", Name: "lights", Array: "4", Location: -1, Line: 15},
+		{Storage: "uniform", Type: "mat4", Name: "view", Location: -1, Line: 20},
+		{Storage: "uniform", Type: "mat4", Name: "projection", Location: -1, Line: 20},
+	}
+	if !reflect.DeepEqual(it.Inputs, inputs) {
+		t.Errorf("inputs %+v, want %+v", it.Inputs, inputs)
+	}
+	if !reflect.DeepEqual(it.Outputs, outputs) {
+		t.Errorf("outputs %+v, want %+v", it.Outputs, outputs)
+	}
+	if !reflect.DeepEqual(it.Uniforms, uniforms) {
+		t.Errorf("uniforms %+v, want %+v", it.Uniforms, uniforms)
+	}
+
+	block := []Declaration{
+		{Type: "vec3", Name: "normal", Location: -1, Line: 16},
+		{Type: "int", Name: "layer", Location: -1, Line: 16, Interpolation: "flat"},
+	}
+	if !reflect.DeepEqual(it.Structs["VS_OUT"], block) {
+		t.Errorf("block members %+v, want %+v", it.Structs["VS_OUT"], block)
+	}
+	if n := len(it.Structs["Light"]); n != 2 {
+		t.Errorf("struct Light has %d members, want 2", n)
+	}
+
+	for name, typ := range map[string]string{
+		"bones[3]":           "mat4",
+		"lights[1].radius":   "float",
+		"lights[0].position": "vec3",
+		"projection":         "mat4",
+	} {
+		if d, ok := it.Uniform(name); !ok || d.Type != typ {
+			t.Errorf("uniform %s = %+v, %v, want type %s", name, d, ok, typ)
+		}
+	}
+	for _, name := range []string{"local", "lights[1].color", "Matrices", "commented"} {
+		if _, ok := it.Uniform(name); ok {
+			t.Errorf("found uniform %s", name)
+		}
+	}
+	if _, ok := it.Output("commented"); ok {
+		t.Error("found the output in a comment")
+	}
+}
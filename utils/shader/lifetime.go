@@ -0,0 +1,158 @@
+package shader
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Delete deletes the program, the shader can not be used after that.
+func (s *Shader) Delete() {
+	s.Unwatch()
+	if s.ID != 0 {
+		gl.DeleteProgram(s.ID)
+		s.ID = 0
+	}
+	untrack(s)
+}
+
+// Delete deletes all the compiled variants.
+func (v *Variants) Delete() {
+	for key, vr := range v.variants {
+		if vr.shader != nil {
+			vr.shader.Delete()
+		}
+		delete(v.variants, key)
+	}
+}
+
+// Leak is a program that has not been deleted.
+type Leak struct {
+	Program   uint32 // the program ID
+	Stack     string // the stack trace where the program was created
+	Collected bool   // the Shader was garbage collected without calling Delete
+}
+
+func (l Leak) String() string {
+	state := "live"
+	if l.Collected {
+		state = "garbage collected"
+	}
+	return fmt.Sprintf("program %d (%s) created at:\n%s", l.Program, state, l.Stack)
+}
+
+var leaks = struct {
+	sync.Mutex
+	enabled bool
+	seq     uint64
+	records map[uint64]*Leak
+}{records: make(map[uint64]*Leak)}
+
+// SetLeakDetection enables or disables the leak detection of the programs,
+// it should be enabled at startup before any shader is created.
+// When enabled, the stack trace is recorded for every program created,
+// and the programs not deleted are reported by Leaks and ReportLeaks, usually at shutdown.
+func SetLeakDetection(enabled bool) {
+	leaks.Lock()
+	defer leaks.Unlock()
+	leaks.enabled = enabled
+}
+
+// Leaks returns the programs created while the leak detection is enabled and not deleted yet.
+func Leaks() []Leak {
+	leaks.Lock()
+	defer leaks.Unlock()
+
+	var ids []uint64
+	for id := range leaks.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var list []Leak
+	for _, id := range ids {
+		list = append(list, *leaks.records[id])
+	}
+	return list
+}
+
+// ReportLeaks writes the leaked programs to w, it returns the number of the leaked programs.
+func ReportLeaks(w io.Writer) int {
+	list := Leaks()
+	for _, l := range list {
+		fmt.Fprintln(w, l)
+	}
+	return len(list)
+}
+
+// track records the stack trace of the newly created shader if the leak detection is enabled.
+func track(s *Shader) {
+	leaks.Lock()
+	defer leaks.Unlock()
+	if !leaks.enabled {
+		return
+	}
+
+	leaks.seq++
+	s.leakID = leaks.seq
+	leaks.records[s.leakID] = &Leak{
+		Program: s.ID,
+		Stack:   stack(3),
+	}
+
+	// the program can not be deleted here as the finalizer does not run on the GL thread,
+	// it is only marked to tell the leaks of unreachable shaders from the live ones.
+	id := s.leakID
+	runtime.SetFinalizer(s, func(*Shader) {
+		leaks.Lock()
+		defer leaks.Unlock()
+		if l, ok := leaks.records[id]; ok {
+			l.Collected = true
+		}
+	})
+}
+
+// untrack removes the record of the deleted shader.
+func untrack(s *Shader) {
+	if s.leakID == 0 {
+		return
+	}
+	leaks.Lock()
+	defer leaks.Unlock()
+	delete(leaks.records, s.leakID)
+	s.leakID = 0
+	runtime.SetFinalizer(s, nil)
+}
+
+// retrack updates the program ID of the shader record after hot reload.
+func retrack(s *Shader) {
+	if s.leakID == 0 {
+		return
+	}
+	leaks.Lock()
+	defer leaks.Unlock()
+	if l, ok := leaks.records[s.leakID]; ok {
+		l.Program = s.ID
+	}
+}
+
+// stack returns the stack trace of the caller, skip is the number of frames to skip as runtime.Callers.
+func stack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var trace string
+	for {
+		frame, more := frames.Next()
+		trace += fmt.Sprintf("\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return trace
+}
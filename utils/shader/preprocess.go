@@ -0,0 +1,198 @@
+package shader
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	includeRe = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*(//.*)?$`)
+	versionRe = regexp.MustCompile(`^\s*#\s*version\b`)
+)
+
+// Location is the position of a line in the original shader files.
+type Location struct {
+	File string // the file name, or "<source>"/"<define>" for code not read from file
+	Line int    // 1-based line number
+}
+
+func (loc Location) String() string {
+	return fmt.Sprintf("%s:%d", loc.File, loc.Line)
+}
+
+// Source is a preprocessed shader source.
+type Source struct {
+	Name  string     // the root file name, or "<source>" if the code is not read from file
+	Code  string     // the source code passed to the compiler
+	Lines []Location // Lines[i] is the origin of the line i+1 of Code
+	Files []string   // all the files the source is read from, including the included files
+}
+
+// Locate returns the original location of the line (1-based) of the preprocessed code.
+func (src *Source) Locate(line int) (Location, bool) {
+	if src == nil || line < 1 || line > len(src.Lines) {
+		return Location{}, false
+	}
+	return src.Lines[line-1], true
+}
+
+// Preprocessor resolves #include "file" directives relative to the including file
+// and injects #define directives right after the #version directive.
+type Preprocessor struct {
+	Defines map[string]string // the macros to define, an empty value defines the name only
+	FS      fs.FS             // the file system to read files from, nil for the OS file system
+}
+
+// ProcessFile preprocesses the shader file.
+func (p *Preprocessor) ProcessFile(file string) (*Source, error) {
+	var b strings.Builder
+	src := &Source{Name: p.clean(file)}
+	if err := p.include(&b, src, file, nil); err != nil {
+		return nil, err
+	}
+	src.Code = b.String()
+	p.define(src)
+	return src, nil
+}
+
+// ProcessSource preprocesses the shader source code,
+// the included files are resolved relative to the current working directory,
+// or the root of the file system if FS is set.
+func (p *Preprocessor) ProcessSource(source string) (*Source, error) {
+	var b strings.Builder
+	src := &Source{Name: "<source>"}
+	if err := p.process(&b, src, src.Name, ".", source, nil); err != nil {
+		return nil, err
+	}
+	src.Code = b.String()
+	p.define(src)
+	return src, nil
+}
+
+func (p *Preprocessor) include(b *strings.Builder, src *Source, file string, stack []string) error {
+	file = p.clean(file)
+	for _, f := range stack {
+		if f == file {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), file)
+		}
+	}
+
+	data, err := p.readFile(file)
+	if err != nil {
+		return err
+	}
+	src.addFile(file)
+
+	return p.process(b, src, file, p.dir(file), string(data), append(stack, file))
+}
+
+func (p *Preprocessor) process(b *strings.Builder, src *Source, name, dir, code string, stack []string) error {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	for i, line := range lines {
+		m := includeRe.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+		if m == nil {
+			b.WriteString(line)
+			b.WriteByte('\n')
+			src.Lines = append(src.Lines, Location{File: name, Line: i + 1})
+			continue
+		}
+
+		file := p.join(dir, m[1])
+		if err := p.include(b, src, file, stack); err != nil {
+			return fmt.Errorf("%s:%d: %v", name, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (p *Preprocessor) readFile(file string) ([]byte, error) {
+	if p.FS != nil {
+		return fs.ReadFile(p.FS, file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// clean, dir and join handle the file names of FS, which are always slash-separated and relative to the root.
+func (p *Preprocessor) clean(file string) string {
+	if p.FS != nil {
+		return path.Clean(file)
+	}
+	return filepath.Clean(file)
+}
+
+func (p *Preprocessor) dir(file string) string {
+	if p.FS != nil {
+		return path.Dir(file)
+	}
+	return filepath.Dir(file)
+}
+
+func (p *Preprocessor) join(dir, file string) string {
+	if p.FS != nil {
+		if path.IsAbs(file) {
+			return path.Clean(file[1:])
+		}
+		return path.Join(dir, file)
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
+
+// define inserts the #define directives after the #version directive,
+// or at the beginning if there is no #version directive.
+func (p *Preprocessor) define(src *Source) {
+	if len(p.Defines) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(p.Defines))
+	for name := range p.Defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := strings.SplitAfter(src.Code, "\n")
+	pos := 0
+	for i, line := range lines {
+		if versionRe.MatchString(line) {
+			pos = i + 1
+			break
+		}
+	}
+
+	var b strings.Builder
+	var locs []Location
+	for _, line := range lines[:pos] {
+		b.WriteString(line)
+	}
+	locs = append(locs, src.Lines[:pos]...)
+	for i, name := range names {
+		b.WriteString(strings.TrimSpace("#define " + name + " " + p.Defines[name]))
+		b.WriteByte('\n')
+		locs = append(locs, Location{File: "<define>", Line: i + 1})
+	}
+	for _, line := range lines[pos:] {
+		b.WriteString(line)
+	}
+	locs = append(locs, src.Lines[pos:]...)
+
+	src.Code = b.String()
+	src.Lines = locs
+}
+
+func (src *Source) addFile(file string) {
+	for _, f := range src.Files {
+		if f == file {
+			return
+		}
+	}
+	src.Files = append(src.Files, file)
+}
@@ -0,0 +1,112 @@
+package shader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreprocessInclude(t *testing.T) {
+	p := &Preprocessor{FS: fstest.MapFS{
+		"shaders/main.frag":   {Data: []byte("#version 330 core\n#include \"common.glsl\"\n#include \"/lib/noise.glsl\" // root\nvoid main() {}\n")},
+		"shaders/common.glsl": {Data: []byte("#include \"../lib/math.glsl\"\nuniform float time;\n")},
+		"lib/math.glsl":       {Data: []byte("const float PI = 3.14159;\n")},
+		"lib/noise.glsl":      {Data: []byte("float noise(vec2 p);\n")},
+	}}
+	src, err := p.ProcessFile("shaders/main.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := "#version 330 core\nconst float PI = 3.14159;\nuniform float time;\nfloat noise(vec2 p);\nvoid main() {}\n"
+	if src.Code != code {
+		t.Errorf("code %q, want %q", src.Code, code)
+	}
+	lines := []Location{
+		{"shaders/main.frag", 1},
+		{"lib/math.glsl", 1},
+		{"shaders/common.glsl", 2},
+		{"lib/noise.glsl", 1},
+		{"shaders/main.frag", 4},
+	}
+	if !reflect.DeepEqual(src.Lines, lines) {
+		t.Errorf("lines %v, want %v", src.Lines, lines)
+	}
+	files := []string{"shaders/main.frag", "shaders/common.glsl", "lib/math.glsl", "lib/noise.glsl"}
+	if !reflect.DeepEqual(src.Files, files) {
+		t.Errorf("files %v, want %v", src.Files, files)
+	}
+	if loc, ok := src.Locate(4); !ok || loc != (Location{"lib/noise.glsl", 1}) {
+		t.Errorf("Locate(4) = %v, %v", loc, ok)
+	}
+	if _, ok := src.Locate(6); ok {
+		t.Error("Locate(6) found a line out of the source")
+	}
+}
+
+func TestPreprocessIncludeErrors(t *testing.T) {
+	p := &Preprocessor{FS: fstest.MapFS{
+		"a.glsl":    {Data: []byte("// a\n#include \"b.glsl\"\n")},
+		"b.glsl":    {Data: []byte("#include \"a.glsl\"\n")},
+		"self.glsl": {Data: []byte("#include \"./self.glsl\"\n")},
+		"miss.glsl": {Data: []byte("\n\n#include \"none.glsl\"\n")},
+	}}
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"a.glsl", "a.glsl:2: b.glsl:1: include cycle: a.glsl -> b.glsl -> a.glsl"},
+		{"self.glsl", "self.glsl:1: include cycle: self.glsl -> self.glsl"},
+		{"miss.glsl", "miss.glsl:3: "},
+	}
+	for _, tt := range tests {
+		_, err := p.ProcessFile(tt.file)
+		if err == nil {
+			t.Errorf("%s: no error", tt.file)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: error %q, want %q", tt.file, err, tt.want)
+		}
+	}
+}
+
+func TestPreprocessDefines(t *testing.T) {
+	p := &Preprocessor{Defines: map[string]string{"SHADOWS": "", "LIGHTS": "4"}}
+	tests := []struct {
+		name   string
+		source string
+		code   string
+		lines  []Location
+	}{
+		{
+			name:   "after version",
+			source: "// header\n#version 330 core\nvoid main() {}\n",
+			code:   "// header\n#version 330 core\n#define LIGHTS 4\n#define SHADOWS\nvoid main() {}\n",
+			lines: []Location{
+				{"<source>", 1}, {"<source>", 2}, {"<define>", 1}, {"<define>", 2}, {"<source>", 3},
+			},
+		},
+		{
+			name:   "no version",
+			source: "void main() {}\n",
+			code:   "#define LIGHTS 4\n#define SHADOWS\nvoid main() {}\n",
+			lines:  []Location{{"<define>", 1}, {"<define>", 2}, {"<source>", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := p.ProcessSource(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.Code != tt.code {
+				t.Errorf("code %q, want %q", src.Code, tt.code)
+			}
+			if !reflect.DeepEqual(src.Lines, tt.lines) {
+				t.Errorf("lines %v, want %v", src.Lines, tt.lines)
+			}
+		})
+	}
+}
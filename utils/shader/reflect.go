@@ -0,0 +1,225 @@
+package shader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Variable is an active uniform or vertex attribute of a program.
+type Variable struct {
+	Name     string // the name reported by the driver, arrays are suffixed with "[0]"
+	Type     uint32 // the GL type, e.g. gl.FLOAT_VEC3
+	Size     int32  // the number of array elements, 1 for non-array variables
+	Location int32  // the location, -1 for uniforms in uniform blocks
+	Block    int32  // the uniform block index, -1 for uniforms in the default block
+}
+
+// TypeName returns the GLSL name of the type, e.g. "vec3".
+func (v Variable) TypeName() string {
+	return TypeName(v.Type)
+}
+
+func (v Variable) String() string {
+	if v.Size > 1 {
+		return fmt.Sprintf("%s %s[%d] (location %d)", v.TypeName(), strings.TrimSuffix(v.Name, "[0]"), v.Size, v.Location)
+	}
+	return fmt.Sprintf("%s %s (location %d)", v.TypeName(), v.Name, v.Location)
+}
+
+var typeNames = map[uint32]string{
+	gl.FLOAT:             "float",
+	gl.FLOAT_VEC2:        "vec2",
+	gl.FLOAT_VEC3:        "vec3",
+	gl.FLOAT_VEC4:        "vec4",
+	gl.DOUBLE:            "double",
+	gl.DOUBLE_VEC2:       "dvec2",
+	gl.DOUBLE_VEC3:       "dvec3",
+	gl.DOUBLE_VEC4:       "dvec4",
+	gl.INT:               "int",
+	gl.INT_VEC2:          "ivec2",
+	gl.INT_VEC3:          "ivec3",
+	gl.INT_VEC4:          "ivec4",
+	gl.UNSIGNED_INT:      "uint",
+	gl.UNSIGNED_INT_VEC2: "uvec2",
+	gl.UNSIGNED_INT_VEC3: "uvec3",
+	gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL:              "bool",
+	gl.BOOL_VEC2:         "bvec2",
+	gl.BOOL_VEC3:         "bvec3",
+	gl.BOOL_VEC4:         "bvec4",
+	gl.FLOAT_MAT2:        "mat2",
+	gl.FLOAT_MAT3:        "mat3",
+	gl.FLOAT_MAT4:        "mat4",
+	gl.FLOAT_MAT2x3:      "mat2x3",
+	gl.FLOAT_MAT2x4:      "mat2x4",
+	gl.FLOAT_MAT3x2:      "mat3x2",
+	gl.FLOAT_MAT3x4:      "mat3x4",
+	gl.FLOAT_MAT4x2:      "mat4x2",
+	gl.FLOAT_MAT4x3:      "mat4x3",
+	gl.DOUBLE_MAT2:       "dmat2",
+	gl.DOUBLE_MAT3:       "dmat3",
+	gl.DOUBLE_MAT4:       "dmat4",
+	gl.DOUBLE_MAT2x3:     "dmat2x3",
+	gl.DOUBLE_MAT2x4:     "dmat2x4",
+	gl.DOUBLE_MAT3x2:     "dmat3x2",
+	gl.DOUBLE_MAT3x4:     "dmat3x4",
+	gl.DOUBLE_MAT4x2:     "dmat4x2",
+	gl.DOUBLE_MAT4x3:     "dmat4x3",
+
+	gl.SAMPLER_1D:                    "sampler1D",
+	gl.SAMPLER_2D:                    "sampler2D",
+	gl.SAMPLER_3D:                    "sampler3D",
+	gl.SAMPLER_CUBE:                  "samplerCube",
+	gl.SAMPLER_1D_SHADOW:             "sampler1DShadow",
+	gl.SAMPLER_2D_SHADOW:             "sampler2DShadow",
+	gl.SAMPLER_1D_ARRAY:              "sampler1DArray",
+	gl.SAMPLER_2D_ARRAY:              "sampler2DArray",
+	gl.SAMPLER_2D_ARRAY_SHADOW:       "sampler2DArrayShadow",
+	gl.SAMPLER_CUBE_SHADOW:           "samplerCubeShadow",
+	gl.SAMPLER_2D_MULTISAMPLE:        "sampler2DMS",
+	gl.SAMPLER_BUFFER:                "samplerBuffer",
+	gl.SAMPLER_2D_RECT:               "sampler2DRect",
+	gl.INT_SAMPLER_2D:                "isampler2D",
+	gl.INT_SAMPLER_3D:                "isampler3D",
+	gl.INT_SAMPLER_CUBE:              "isamplerCube",
+	gl.INT_SAMPLER_2D_ARRAY:          "isampler2DArray",
+	gl.UNSIGNED_INT_SAMPLER_2D:       "usampler2D",
+	gl.UNSIGNED_INT_SAMPLER_3D:       "usampler3D",
+	gl.UNSIGNED_INT_SAMPLER_CUBE:     "usamplerCube",
+	gl.UNSIGNED_INT_SAMPLER_2D_ARRAY: "usampler2DArray",
+}
+
+// TypeName returns the GLSL name of the GL type, e.g. "vec3" for gl.FLOAT_VEC3.
+func TypeName(t uint32) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("type(0x%x)", t)
+}
+
+// reflection is the introspection data of a linked program.
+type reflection struct {
+	uniforms   []Variable
+	attributes []Variable
+	locations  map[string]int32 // cached uniform locations by name
+}
+
+// reflectProgram introspects the active uniforms and attributes of the program.
+func reflectProgram(program uint32) *reflection {
+	r := &reflection{
+		locations: make(map[string]int32),
+	}
+
+	var count, maxLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	for i := uint32(0); i < uint32(count); i++ {
+		v := Variable{Location: -1}
+		v.Name = activeName(maxLength, func(buf *uint8, length *int32) {
+			gl.GetActiveUniform(program, i, maxLength, length, &v.Size, &v.Type, buf)
+		})
+		gl.GetActiveUniformsiv(program, 1, &i, gl.UNIFORM_BLOCK_INDEX, &v.Block)
+		if v.Block < 0 {
+			v.Location = gl.GetUniformLocation(program, gl.Str(v.Name+"\x00"))
+			r.locations[v.Name] = v.Location
+			if strings.HasSuffix(v.Name, "[0]") {
+				// the array can also be referred without index.
+				r.locations[strings.TrimSuffix(v.Name, "[0]")] = v.Location
+			}
+		}
+		r.uniforms = append(r.uniforms, v)
+	}
+
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+	for i := uint32(0); i < uint32(count); i++ {
+		v := Variable{Block: -1}
+		v.Name = activeName(maxLength, func(buf *uint8, length *int32) {
+			gl.GetActiveAttrib(program, i, maxLength, length, &v.Size, &v.Type, buf)
+		})
+		v.Location = gl.GetAttribLocation(program, gl.Str(v.Name+"\x00"))
+		r.attributes = append(r.attributes, v)
+	}
+
+	sort.Slice(r.uniforms, func(i, j int) bool { return r.uniforms[i].Name < r.uniforms[j].Name })
+	sort.Slice(r.attributes, func(i, j int) bool { return r.attributes[i].Location < r.attributes[j].Location })
+
+	return r
+}
+
+func activeName(maxLength int32, get func(buf *uint8, length *int32)) string {
+	if maxLength <= 0 {
+		return ""
+	}
+	var length int32
+	buf := make([]uint8, maxLength)
+	get(&buf[0], &length)
+	return string(buf[:length])
+}
+
+// Uniforms returns the active uniforms of the program sorted by name.
+func (s *Shader) Uniforms() []Variable {
+	if s.reflection == nil {
+		return nil
+	}
+	return s.reflection.uniforms
+}
+
+// Attributes returns the active vertex attributes of the program sorted by location.
+func (s *Shader) Attributes() []Variable {
+	if s.reflection == nil {
+		return nil
+	}
+	return s.reflection.attributes
+}
+
+// Uniform returns the active uniform by name,
+// the name of an array can be given with or without the "[0]" suffix.
+func (s *Shader) Uniform(name string) (Variable, bool) {
+	name = strings.TrimSuffix(name, "\x00")
+	for _, v := range s.Uniforms() {
+		if v.Name == name || v.Name == name+"[0]" {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Attribute returns the active vertex attribute by name.
+func (s *Shader) Attribute(name string) (Variable, bool) {
+	name = strings.TrimSuffix(name, "\x00")
+	for _, v := range s.Attributes() {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// UniformLocation returns the cached location of the uniform,
+// the name can also be an array element like "bones[3]" or a struct member like "material.diffuse".
+// An error is returned if the program does not declare the uniform or the uniform is not used,
+// as the compiler removes the unused uniforms.
+func (s *Shader) UniformLocation(name string) (int32, error) {
+	name = strings.TrimSuffix(name, "\x00")
+	if s.reflection == nil {
+		s.reflection = reflectProgram(s.ID)
+	}
+	if location, ok := s.reflection.locations[name]; ok {
+		if location < 0 {
+			return -1, fmt.Errorf("uniform %q is not an active uniform of the program", name)
+		}
+		return location, nil
+	}
+
+	// the elements of arrays are not listed by the driver, query and cache them.
+	location := gl.GetUniformLocation(s.ID, gl.Str(name+"\x00"))
+	s.reflection.locations[name] = location
+	if location < 0 {
+		return -1, fmt.Errorf("uniform %q is not an active uniform of the program", name)
+	}
+	return location, nil
+}
@@ -0,0 +1,141 @@
+package shader
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// DefaultWatchInterval is the interval of checking the shader files when hot reload is enabled.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+type watcher struct {
+	interval time.Duration
+	onError  func(error)
+	modTimes map[string]time.Time
+	checked  time.Time
+}
+
+// Watch enables hot reload of the shader program.
+// The shader files are polled by their modification time every interval
+// (DefaultWatchInterval if interval is not positive) when Reload is called.
+// onError, if not nil, receives the compile or link log of a failed reload.
+// The files of an embed.FS have no modification time, so they are never reloaded.
+func (s *Shader) Watch(interval time.Duration, onError func(err error)) error {
+	if s.builder == nil || len(s.files) == 0 {
+		return errors.New("shader is not built from files")
+	}
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	w := &watcher{
+		interval: interval,
+		onError:  onError,
+		modTimes: make(map[string]time.Time),
+	}
+	for _, file := range s.files {
+		fi, err := s.stat(file)
+		if err != nil {
+			return err
+		}
+		w.modTimes[file] = fi.ModTime()
+	}
+	w.checked = time.Now()
+	s.watcher = w
+
+	return nil
+}
+
+// Unwatch disables hot reload of the shader program.
+func (s *Shader) Unwatch() {
+	s.watcher = nil
+}
+
+// Reload recompiles the shader program if any of its files (including the included files) has changed,
+// it must be called from the thread owning the GL context, usually once per frame in the render loop.
+// The program ID is swapped only when all stages compile and link successfully,
+// otherwise the old program is kept and the error is reported to the onError callback of Watch.
+// Reload returns true if the program has been swapped,
+// the uniforms must be set again as they are not carried over to the new program.
+func (s *Shader) Reload() bool {
+	w := s.watcher
+	if w == nil {
+		return false
+	}
+	now := time.Now()
+	if now.Sub(w.checked) < w.interval {
+		return false
+	}
+	w.checked = now
+
+	changed := false
+	for file, modTime := range w.modTimes {
+		fi, err := s.stat(file)
+		if err != nil {
+			// the file may be in the middle of saving, try it again on next check.
+			continue
+		}
+		if !fi.ModTime().Equal(modTime) {
+			w.modTimes[file] = fi.ModTime()
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+
+	shader, err := s.builder.Build()
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return false
+	}
+	s.swap(shader)
+
+	return true
+}
+
+// swap replaces the program and sources of s with the newly built shader,
+// the old program is deleted.
+func (s *Shader) swap(shader *Shader) {
+	old := s.ID
+	// the program is taken over by s, so only s is tracked.
+	untrack(shader)
+
+	s.ID = shader.ID
+	s.reflection = shader.reflection
+	s.warnings = shader.warnings
+	for _, stage := range stageOrder {
+		s.setSource(stage, shader.Source(stage))
+	}
+	s.files = shader.files
+	if w := s.watcher; w != nil {
+		// watch the newly included files as well.
+		for _, file := range s.files {
+			if _, ok := w.modTimes[file]; ok {
+				continue
+			}
+			if fi, err := s.stat(file); err == nil {
+				w.modTimes[file] = fi.ModTime()
+			}
+		}
+	}
+
+	if old != 0 {
+		gl.DeleteProgram(old)
+	}
+	retrack(s)
+}
+
+// stat returns the file info of the shader file in the file system of the builder.
+func (s *Shader) stat(file string) (fs.FileInfo, error) {
+	if s.builder != nil && s.builder.fsys != nil {
+		return fs.Stat(s.builder.fsys, file)
+	}
+	return os.Stat(file)
+}
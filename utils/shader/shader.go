@@ -3,39 +3,106 @@ package shader
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"strings"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
 )
 
-// Shader is a compiled shader program contains vertex and fragment shaders.
+// Shader is a compiled shader program contains vertex and fragment shaders,
+// and optionally tessellation and geometry shaders, or a single compute shader.
 type Shader struct {
-	ID             uint32 // the program ID
-	VertexSource   string // vertex shader source code
-	FragmentSource string // fragment shader source code
+	ID                   uint32 // the program ID
+	VertexSource         string // vertex shader source code
+	TessControlSource    string // tessellation control shader source code
+	TessEvaluationSource string // tessellation evaluation shader source code
+	GeometrySource       string // geometry shader source code
+	FragmentSource       string // fragment shader source code
+	ComputeSource        string // compute shader source code
+
+	builder *Builder // the builder used to build the program
+	files   []string // the shader files and their included files
+	watcher *watcher // non-nil if hot reload is enabled
+
+	warnings []Diagnostic // the problems found in the interfaces between the stages
+	leakID   uint64       // the record ID of the leak detection, 0 if not tracked
+
+	reflection *reflection // the active uniforms and attributes
 }
 
 // NewShader creates a shader program, it reads shader source from shader files.
 func NewShader(vertexFile, fragmentFile string) (*Shader, error) {
-	vertexSource, err := ioutil.ReadFile(vertexFile)
-	if err != nil {
-		return nil, err
+	return NewBuilder().
+		File(VertexStage, vertexFile).
+		File(FragmentStage, fragmentFile).
+		Build()
+}
+
+// NewShaderFS creates a shader program, it reads shader source from shader files in the file system.
+//
+//	//go:embed shaders
+//	var shaders embed.FS
+//
+//	shader, err := shader.NewShaderFS(shaders, "shaders/7.2.camera.vs", "shaders/7.2.camera.fs")
+func NewShaderFS(fsys fs.FS, vertexFile, fragmentFile string) (*Shader, error) {
+	return NewBuilder().
+		FS(fsys).
+		File(VertexStage, vertexFile).
+		File(FragmentStage, fragmentFile).
+		Build()
+}
+
+// Source returns the source code of the given stage,
+// an empty string means the stage is not attached.
+func (s *Shader) Source(stage Stage) string {
+	switch stage {
+	case VertexStage:
+		return s.VertexSource
+	case TessControlStage:
+		return s.TessControlSource
+	case TessEvaluationStage:
+		return s.TessEvaluationSource
+	case GeometryStage:
+		return s.GeometrySource
+	case FragmentStage:
+		return s.FragmentSource
+	case ComputeStage:
+		return s.ComputeSource
 	}
-	fragmentSource, err := ioutil.ReadFile(fragmentFile)
-	if err != nil {
-		return nil, err
+	return ""
+}
+
+// Files returns the files the program is built from, including the included files.
+func (s *Shader) Files() []string {
+	return s.files
+}
+
+func (s *Shader) addFile(file string) {
+	for _, f := range s.files {
+		if f == file {
+			return
+		}
 	}
-	program, err := newPragram(string(vertexSource), string(fragmentSource))
-	if err != nil {
-		return nil, err
+	s.files = append(s.files, file)
+}
+
+func (s *Shader) setSource(stage Stage, source string) {
+	switch stage {
+	case VertexStage:
+		s.VertexSource = source
+	case TessControlStage:
+		s.TessControlSource = source
+	case TessEvaluationStage:
+		s.TessEvaluationSource = source
+	case GeometryStage:
+		s.GeometrySource = source
+	case FragmentStage:
+		s.FragmentSource = source
+	case ComputeStage:
+		s.ComputeSource = source
 	}
-	return &Shader{
-		ID:             program,
-		VertexSource:   string(vertexSource),
-		FragmentSource: string(fragmentSource),
-	}, nil
 }
 
 // Use activates the shader
@@ -45,18 +112,20 @@ func (s *Shader) Use() {
 
 // SetUniformName sets uniform by name.
 // The number of values must be 1, 2, 3 or 4.
-// the type of values must be int32, uint32, float32 or float64
+// the type of values must be int32, uint32, float32 or float64,
+// or a single vector, matrix or slice accepted by SetUniformVec and SetUniformMatrix.
 func (s *Shader) SetUniformName(name string, v ...interface{}) error {
-	if !strings.HasSuffix(name, "\x00") {
-		name += "\x00"
+	location, err := s.UniformLocation(name)
+	if err != nil {
+		return err
 	}
-	location := gl.GetUniformLocation(s.ID, gl.Str(name))
 	return s.SetUniform(location, v...)
 }
 
 // SetUniform sets uniform by location.
 // The number of values must be 1, 2, 3 or 4.
-// the type of values must be int, int32, uint32, float32 or float64
+// the type of values must be int, int32, uint32, float32 or float64,
+// or a single vector, matrix or slice accepted by SetUniformVec and SetUniformMatrix.
 func (s *Shader) SetUniform(uniform int32, v ...interface{}) error {
 	if uniform < 0 {
 		return errors.New("invalid uniform")
@@ -116,6 +185,21 @@ func (s *Shader) SetUniform(uniform int32, v ...interface{}) error {
 			vv[i] = tv
 		}
 		s.SetUniformd(uniform, vv...)
+	case mgl32.Vec2, mgl32.Vec3, mgl32.Vec4, mgl64.Vec2, mgl64.Vec3, mgl64.Vec4,
+		[]mgl32.Vec2, []mgl32.Vec3, []mgl32.Vec4, []mgl64.Vec2, []mgl64.Vec3, []mgl64.Vec4,
+		[]float32, []float64, []int32, []uint32:
+		if len(v) > 1 {
+			return errors.New("too many values, use a slice for arrays")
+		}
+		return s.SetUniformVec(uniform, v[0])
+	case mgl32.Mat2, mgl32.Mat2x3, mgl32.Mat2x4, mgl32.Mat3x2, mgl32.Mat3, mgl32.Mat3x4, mgl32.Mat4x2, mgl32.Mat4x3, mgl32.Mat4,
+		mgl64.Mat2, mgl64.Mat2x3, mgl64.Mat2x4, mgl64.Mat3x2, mgl64.Mat3, mgl64.Mat3x4, mgl64.Mat4x2, mgl64.Mat4x3, mgl64.Mat4,
+		[]mgl32.Mat2, []mgl32.Mat2x3, []mgl32.Mat2x4, []mgl32.Mat3x2, []mgl32.Mat3, []mgl32.Mat3x4, []mgl32.Mat4x2, []mgl32.Mat4x3, []mgl32.Mat4,
+		[]mgl64.Mat2, []mgl64.Mat2x3, []mgl64.Mat2x4, []mgl64.Mat3x2, []mgl64.Mat3, []mgl64.Mat3x4, []mgl64.Mat4x2, []mgl64.Mat4x3, []mgl64.Mat4:
+		if len(v) > 1 {
+			return errors.New("too many values, use a slice for arrays")
+		}
+		return s.SetUniformMatrix(uniform, false, v[0])
 	default:
 		return errors.New("unsupported value type")
 	}
@@ -211,62 +295,214 @@ func (s *Shader) SetUniformd(uniform int32, v ...float64) error {
 	return nil
 }
 
+// SetUniformMatrixName sets matrix uniform by name, see SetUniformMatrix.
 func (s *Shader) SetUniformMatrixName(name string, transpose bool, mat interface{}) error {
-	if !strings.HasSuffix(name, "\x00") {
-		name += "\x00"
+	location, err := s.UniformLocation(name)
+	if err != nil {
+		return err
 	}
-	location := gl.GetUniformLocation(s.ID, gl.Str(name))
 	return s.SetUniformMatrix(location, transpose, mat)
 }
 
-func (s *Shader) SetUniformMatrix(uniform int32, traspose bool, mat interface{}) error {
+// SetUniformMatrix sets matrix uniform by location.
+// The type of mat must be one of the mgl32 or mgl64 matrices, or a slice of them for arrays.
+// Note that mgl32.MatRxC has R rows and C columns, so it maps to the GLSL type matCxR,
+// e.g. mgl32.Mat2x3 is a mat3x2 in GLSL.
+func (s *Shader) SetUniformMatrix(uniform int32, transpose bool, mat interface{}) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+
 	switch v := mat.(type) {
+	case mgl32.Mat2:
+		gl.UniformMatrix2fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat2x3:
+		gl.UniformMatrix3x2fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat2x3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3x2fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat2x4:
+		gl.UniformMatrix4x2fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat2x4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4x2fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat3x2:
+		gl.UniformMatrix2x3fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat3x2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2x3fv(uniform, int32(len(v)), transpose, &v[0][0])
 	case mgl32.Mat3:
-		gl.UniformMatrix2fv(uniform, 1, traspose, &v[0])
+		gl.UniformMatrix3fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat3x4:
+		gl.UniformMatrix4x3fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat3x4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4x3fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat4x2:
+		gl.UniformMatrix2x4fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat4x2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2x4fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl32.Mat4x3:
+		gl.UniformMatrix3x4fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat4x3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3x4fv(uniform, int32(len(v)), transpose, &v[0][0])
 	case mgl32.Mat4:
-		gl.UniformMatrix4fv(uniform, 1, traspose, &v[0])
+		gl.UniformMatrix4fv(uniform, 1, transpose, &v[0])
+	case []mgl32.Mat4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4fv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat2:
+		gl.UniformMatrix2dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat2x3:
+		gl.UniformMatrix3x2dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat2x3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3x2dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat2x4:
+		gl.UniformMatrix4x2dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat2x4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4x2dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat3x2:
+		gl.UniformMatrix2x3dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat3x2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2x3dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat3:
+		gl.UniformMatrix3dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat3x4:
+		gl.UniformMatrix4x3dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat3x4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4x3dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat4x2:
+		gl.UniformMatrix2x4dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat4x2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix2x4dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat4x3:
+		gl.UniformMatrix3x4dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat4x3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix3x4dv(uniform, int32(len(v)), transpose, &v[0][0])
+	case mgl64.Mat4:
+		gl.UniformMatrix4dv(uniform, 1, transpose, &v[0])
+	case []mgl64.Mat4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.UniformMatrix4dv(uniform, int32(len(v)), transpose, &v[0][0])
 	default:
-		return errors.New("unsupported matrix")
+		return fmt.Errorf("unsupported matrix type %T", mat)
 	}
 	return nil
 }
 
-func newPragram(vertexShaderSource string, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return 0, err
+// newPragram compiles the sources of each stage and links them into a program.
+// The stage combination must have been validated by the caller.
+// If retrievable is true, the driver is hinted that the program binary will be retrieved.
+// The interfaces between the stages are compared, the problems found are returned as warnings,
+// or attached to the LinkError if the program fails to link.
+func newPragram(sources map[Stage]*Source, retrievable bool) (uint32, []Diagnostic, error) {
+	warnings := CheckInterfaces(sources)
+
+	var shaders []uint32
+	for _, stage := range stageOrder {
+		source, ok := sources[stage]
+		if !ok {
+			continue
+		}
+		shader, err := compileShader(source, uint32(stage))
+		if err != nil {
+			deleteShaders(shaders)
+			return 0, nil, err
+		}
+		shaders = append(shaders, shader)
 	}
+	// the shaders are no longer needed once they are linked into the program, or the link fails.
+	defer deleteShaders(shaders)
 
 	program := gl.CreateProgram()
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
+	for _, shader := range shaders {
+		gl.AttachShader(program, shader)
+	}
+	if retrievable {
+		gl.ProgramParameteri(program, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
 	gl.LinkProgram(program)
 
 	var status int32
 	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
 	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-		logs := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(logs))
+		logs := programLog(program)
+		gl.DeleteProgram(program)
+		return 0, nil, &LinkError{Log: logs, Diagnostics: ParseLog(logs, nil), Warnings: warnings}
+	}
 
-		return 0, fmt.Errorf("failed to link program: %v", logs)
+	for _, shader := range shaders {
+		gl.DetachShader(program, shader)
 	}
 
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
+	return program, warnings, nil
+}
 
-	return program, nil
+func deleteShaders(shaders []uint32) {
+	for _, shader := range shaders {
+		gl.DeleteShader(shader)
+	}
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
+func compileShader(source *Source, shaderType uint32) (uint32, error) {
 	shader := gl.CreateShader(shaderType)
-	csources, free := gl.Strs(source)
+	csources, free := gl.Strs(source.Code)
 	gl.ShaderSource(shader, 1, csources, nil)
 	free()
 	gl.CompileShader(shader)
@@ -274,13 +510,39 @@ func compileShader(source string, shaderType uint32) (uint32, error) {
 	var status int32
 	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
 	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-		logs := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(logs))
-
-		return 0, fmt.Errorf("failed to compile %v : %v", source, logs)
+		logs := shaderLog(shader)
+		gl.DeleteShader(shader)
+		return 0, &CompileError{
+			Stage:       Stage(shaderType),
+			File:        source.Name,
+			Log:         logs,
+			Diagnostics: ParseLog(logs, source),
+		}
 	}
 
 	return shader, nil
 }
+
+// shaderLog returns the info log of the shader.
+func shaderLog(shader uint32) string {
+	var logLength int32
+	gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+	if logLength <= 0 {
+		return ""
+	}
+	logs := make([]uint8, logLength)
+	gl.GetShaderInfoLog(shader, logLength, nil, &logs[0])
+	return strings.TrimRight(string(logs), "\x00")
+}
+
+// programLog returns the info log of the program.
+func programLog(program uint32) string {
+	var logLength int32
+	gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+	if logLength <= 0 {
+		return ""
+	}
+	logs := make([]uint8, logLength)
+	gl.GetProgramInfoLog(program, logLength, nil, &logs[0])
+	return strings.TrimRight(string(logs), "\x00")
+}
@@ -0,0 +1,200 @@
+package shader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Profile is the GLSL profile the shader sources are compiled for.
+type Profile int
+
+const (
+	// ProfileGL33 is the desktop OpenGL 3.3 core profile, the sources are compiled as they are.
+	ProfileGL33 Profile = iota
+	// ProfileGLES30 is the OpenGL ES 3.0 profile, also used by WebGL 2.
+	// The sources written for #version 330 core are translated to #version 300 es.
+	ProfileGLES30
+)
+
+func (p Profile) String() string {
+	switch p {
+	case ProfileGL33:
+		return "330 core"
+	case ProfileGLES30:
+		return "300 es"
+	}
+	return fmt.Sprintf("profile(%d)", int(p))
+}
+
+// Target sets the profile the sources are translated for, the default is ProfileGL33.
+//
+// The GL bindings of this package are loaded from a desktop GL 3.3 core context by gl.Init,
+// to run on an OpenGL ES 3.0 or WebGL 2 backend, load the function pointers from that context
+// with gl.InitWithProcAddrFunc, the entry points used by the package exist in OpenGL ES 3.0.
+func (b *Builder) Target(profile Profile) *Builder {
+	b.target = profile
+	return b
+}
+
+var (
+	// layout(location = 0) in/out
+	layoutInOutRe = regexp.MustCompile(`^(\s*)layout\s*\(\s*location\s*=\s*\d+\s*\)\s*((?:(?:flat|smooth|centroid)\s+)*(in|out)\b.*)$`)
+	// layout(binding = 0) is not available before GLSL ES 3.10
+	layoutBindingRe = regexp.MustCompile(`\blayout\s*\([^)]*\bbinding\s*=`)
+	// precision float declaration
+	precisionFloatRe = regexp.MustCompile(`^\s*precision\s+\w+\s+float\s*;`)
+	// features of the core profile that do not exist in GLSL ES 3.00
+	unsupportedRes = []*regexp.Regexp{
+		regexp.MustCompile(`\b(double|dvec[234]|dmat[234](x[234])?)\b`),
+		regexp.MustCompile(`\b(sampler1D\w*|isampler1D\w*|usampler1D\w*|sampler2DRect\w*|samplerBuffer|sampler2DMS\w*)\b`),
+		regexp.MustCompile(`\b(gl_ClipDistance|gl_PrimitiveID|noperspective)\b`),
+	}
+	commentRe = regexp.MustCompile(`//.*$`)
+	// #extension and the conditional directives, which decide where the default precisions go
+	extensionRe = regexp.MustCompile(`^\s*#\s*extension\b`)
+	ifRe        = regexp.MustCompile(`^\s*#\s*if(n?def)?\b`)
+	endifRe     = regexp.MustCompile(`^\s*#\s*endif\b`)
+)
+
+// the default precisions of GLSL ES 3.00 fragment shaders,
+// float and the samplers other than sampler2D and samplerCube have no default precision.
+var esFragmentPrecisions = []string{
+	"precision highp float;",
+	"precision highp int;",
+	"precision mediump sampler3D;",
+	"precision mediump sampler2DArray;",
+	"precision mediump sampler2DShadow;",
+	"precision mediump samplerCubeShadow;",
+	"precision mediump sampler2DArrayShadow;",
+}
+
+// Translate translates the preprocessed source of the stage written for the GL 3.3 core profile to the target profile.
+// For ProfileGLES30, the #version directive is rewritten to "#version 300 es",
+// the default precisions are inserted into fragment shaders after the #extension directives,
+// the location layouts of vertex outputs and fragment inputs are removed as GLSL ES 3.00 only allows
+// them on vertex inputs and fragment outputs, and an error is returned for the core-only features
+// like doubles, 1D samplers, uniform binding layouts and the stages other than vertex and fragment.
+// The line mapping of src is kept, so the compile logs still point back to the original files.
+func Translate(src *Source, stage Stage, target Profile) (*Source, error) {
+	switch target {
+	case ProfileGL33:
+		return src, nil
+	case ProfileGLES30:
+	default:
+		return nil, fmt.Errorf("unsupported profile %v", target)
+	}
+
+	if stage != VertexStage && stage != FragmentStage {
+		return nil, fmt.Errorf("%v shader is not supported by %v", stage, target)
+	}
+
+	out := &Source{Name: src.Name, Files: src.Files}
+	lines := strings.Split(strings.TrimSuffix(src.Code, "\n"), "\n")
+
+	hasPrecision := false
+	for _, line := range lines {
+		if precisionFloatRe.MatchString(line) {
+			hasPrecision = true
+			break
+		}
+	}
+
+	var b strings.Builder
+	precision := stage == FragmentStage && !hasPrecision
+	pos := precisionPos(lines)
+	writePrecisions := func() {
+		for j, p := range esFragmentPrecisions {
+			b.WriteString(p)
+			b.WriteByte('\n')
+			out.Lines = append(out.Lines, Location{File: "<precision>", Line: j + 1})
+		}
+		precision = false
+	}
+
+	version := false
+	for i, line := range lines {
+		loc, _ := src.Locate(i + 1)
+		code := commentRe.ReplaceAllString(line, "")
+
+		for _, re := range unsupportedRes {
+			if m := re.FindString(code); m != "" {
+				return nil, fmt.Errorf("%v: %q is not supported by %v", loc, m, target)
+			}
+		}
+		if layoutBindingRe.MatchString(code) {
+			return nil, fmt.Errorf("%v: binding layout is not supported by %v", loc, target)
+		}
+
+		if !version && versionRe.MatchString(line) {
+			version = true
+			b.WriteString("#version 300 es\n")
+			out.Lines = append(out.Lines, loc)
+			continue
+		}
+		if !version && strings.TrimSpace(code) != "" {
+			// the #version directive must come first, add it if missing.
+			version = true
+			b.WriteString("#version 300 es\n")
+			out.Lines = append(out.Lines, Location{File: "<translate>", Line: 1})
+		}
+		if precision && version && i >= pos {
+			writePrecisions()
+		}
+
+		if m := layoutInOutRe.FindStringSubmatch(line); m != nil {
+			if (stage == VertexStage && m[3] == "out") || (stage == FragmentStage && m[3] == "in") {
+				line = m[1] + m[2]
+			}
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+		out.Lines = append(out.Lines, loc)
+	}
+	if precision && version {
+		writePrecisions()
+	}
+	out.Code = b.String()
+
+	return out, nil
+}
+
+// precisionPos returns the index of the line the default precisions are inserted before.
+// GLSL ES requires the #extension directives to come before any non-preprocessor tokens,
+// so it is the line after the last #extension directive of the leading directives,
+// or after the #endif if the directive is conditional, or the line after #version if there is none.
+func precisionPos(lines []string) int {
+	start := 0
+	for i, line := range lines {
+		if versionRe.MatchString(line) {
+			start = i + 1
+			break
+		}
+		if strings.TrimSpace(commentRe.ReplaceAllString(line, "")) != "" {
+			break
+		}
+	}
+
+	pos, depth, extension := start, 0, false
+	for i := start; i < len(lines); i++ {
+		code := strings.TrimSpace(commentRe.ReplaceAllString(lines[i], ""))
+		if code == "" {
+			continue
+		}
+		if !strings.HasPrefix(code, "#") {
+			break
+		}
+		switch {
+		case extensionRe.MatchString(code):
+			extension = true
+		case ifRe.MatchString(code):
+			depth++
+		case endifRe.MatchString(code):
+			depth--
+		}
+		if extension && depth <= 0 {
+			pos, extension = i+1, false
+		}
+	}
+	return pos
+}
@@ -0,0 +1,138 @@
+package shader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const precisions = "precision highp float;\nprecision highp int;\nprecision mediump sampler3D;\nprecision mediump sampler2DArray;\n" +
+	"precision mediump sampler2DShadow;\nprecision mediump samplerCubeShadow;\nprecision mediump sampler2DArrayShadow;\n"
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  Stage
+		source string
+		code   string
+	}{
+		{
+			name:   "vertex",
+			stage:  VertexStage,
+			source: "#version 330 core\nlayout (location = 0) in vec3 pos;\nlayout (location = 0) out vec2 uv;\nvoid main() {}\n",
+			code:   "#version 300 es\nlayout (location = 0) in vec3 pos;\nout vec2 uv;\nvoid main() {}\n",
+		},
+		{
+			name:   "fragment",
+			stage:  FragmentStage,
+			source: "#version 330 core\nlayout(location=1) flat in int id;\nlayout (location = 0) out vec4 color;\nvoid main() {}\n",
+			code:   "#version 300 es\n" + precisions + "flat in int id;\nlayout (location = 0) out vec4 color;\nvoid main() {}\n",
+		},
+		{
+			name:   "precision declared",
+			stage:  FragmentStage,
+			source: "#version 330 core\nprecision mediump float;\nvoid main() {}\n",
+			code:   "#version 300 es\nprecision mediump float;\nvoid main() {}\n",
+		},
+		{
+			name:   "extension",
+			stage:  FragmentStage,
+			source: "#version 330 core\n#define A\n#extension GL_EXT_shader_framebuffer_fetch : enable\n\n// comment\n#extension GL_OES_foo : require\nvoid main() {}\n",
+			code: "#version 300 es\n#define A\n#extension GL_EXT_shader_framebuffer_fetch : enable\n\n// comment\n#extension GL_OES_foo : require\n" +
+				precisions + "void main() {}\n",
+		},
+		{
+			name:   "conditional extension",
+			stage:  FragmentStage,
+			source: "#version 330 core\n#ifdef GL_OES_foo\n#extension GL_OES_foo : enable\n#endif\nvoid main() {}\n",
+			code:   "#version 300 es\n#ifdef GL_OES_foo\n#extension GL_OES_foo : enable\n#endif\n" + precisions + "void main() {}\n",
+		},
+		{
+			name:   "no version",
+			stage:  FragmentStage,
+			source: "\n#extension GL_OES_foo : enable\nvoid main() {}\n",
+			code:   "\n#version 300 es\n#extension GL_OES_foo : enable\n" + precisions + "void main() {}\n",
+		},
+		{
+			name:   "directives only",
+			stage:  FragmentStage,
+			source: "#version 330 core\n#extension GL_OES_foo : enable\n",
+			code:   "#version 300 es\n#extension GL_OES_foo : enable\n" + precisions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := (&Preprocessor{}).ProcessSource(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := Translate(src, tt.stage, ProfileGLES30)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Code != tt.code {
+				t.Errorf("code\n%s\nwant\n%s", out.Code, tt.code)
+			}
+			if n := strings.Count(out.Code, "\n"); len(out.Lines) != n {
+				t.Errorf("%d line locations for %d lines", len(out.Lines), n)
+			}
+		})
+	}
+}
+
+func TestTranslateLines(t *testing.T) {
+	src, err := (&Preprocessor{Defines: map[string]string{"A": ""}}).ProcessSource(
+		"#version 330 core\n#extension GL_OES_foo : enable\nout vec4 color;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Translate(src, FragmentStage, ProfileGLES30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Location{{"<source>", 1}, {"<define>", 1}, {"<source>", 2}}
+	for i := range esFragmentPrecisions {
+		want = append(want, Location{"<precision>", i + 1})
+	}
+	want = append(want, Location{"<source>", 3})
+	if !reflect.DeepEqual(out.Lines, want) {
+		t.Errorf("lines %v, want %v", out.Lines, want)
+	}
+
+	if out, err := Translate(src, FragmentStage, ProfileGL33); err != nil || out != src {
+		t.Errorf("GL 3.3 translated the source: %v", err)
+	}
+}
+
+func TestTranslateUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  Stage
+		source string
+		want   string
+	}{
+		{"double", VertexStage, "#version 330 core\nuniform dvec3 pos;\n", "<source>:2: \"dvec3\""},
+		{"sampler1D", FragmentStage, "#version 330 core\nuniform sampler1D ramp;\n", "<source>:2: \"sampler1D\""},
+		{"noperspective", FragmentStage, "#version 330 core\nnoperspective in vec2 uv;\n", "<source>:2: \"noperspective\""},
+		{"binding", FragmentStage, "#version 330 core\nlayout(std140, binding = 1) uniform Lights {};\n", "<source>:2: binding layout"},
+		{"geometry", GeometryStage, "#version 330 core\n", "geometry shader is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := (&Preprocessor{}).ProcessSource(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Translate(src, tt.stage, ProfileGLES30)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// the features in comments are ignored.
+	src, _ := (&Preprocessor{}).ProcessSource("#version 330 core\nout vec4 color; // not a double\n")
+	if _, err := Translate(src, VertexStage, ProfileGLES30); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,103 @@
+package shader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ginuerzh/learnopengl/utils/std140"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// UniformBuffer is a uniform buffer object bound to a binding point,
+// its content is a Go value encoded in std140 layout.
+//
+//	type Matrices struct {
+//		Projection mgl32.Mat4 `glsl:"projection"`
+//		View       mgl32.Mat4 `glsl:"view"`
+//	}
+//
+//	ubo, err := shader.NewUniformBuffer(0, &Matrices{Projection: projection})
+//	err = ubo.Bind("Matrices", shader1, shader2)
+//	err = ubo.Update(&Matrices{Projection: projection, View: view})
+type UniformBuffer struct {
+	ID      uint32 // the buffer ID
+	Binding uint32 // the binding point
+	Size    int    // the size in bytes of the buffer
+}
+
+// NewUniformBuffer creates a uniform buffer with the initial value v, and binds it to the binding point.
+// The size of the buffer is the std140 size of v.
+func NewUniformBuffer(binding uint32, v interface{}) (*UniformBuffer, error) {
+	data, err := std140.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ub := &UniformBuffer{
+		Binding: binding,
+		Size:    len(data),
+	}
+	gl.GenBuffers(1, &ub.ID)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ub.ID)
+	gl.BufferData(gl.UNIFORM_BUFFER, len(data), gl.Ptr(data), gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, ub.ID)
+
+	return ub, nil
+}
+
+// Update uploads the value v to the buffer, v must have the same layout as the initial value.
+func (ub *UniformBuffer) Update(v interface{}) error {
+	data, err := std140.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) != ub.Size {
+		return fmt.Errorf("size mismatch: buffer is %d bytes, value is %d bytes", ub.Size, len(data))
+	}
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ub.ID)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), gl.Ptr(data))
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+
+	return nil
+}
+
+// Bind links the named uniform block of the shader programs to the binding point of the buffer.
+func (ub *UniformBuffer) Bind(block string, shaders ...*Shader) error {
+	for _, s := range shaders {
+		size, err := s.BindUniformBlock(block, ub.Binding)
+		if err != nil {
+			return err
+		}
+		if size > ub.Size {
+			return fmt.Errorf("uniform block %q of program %d is %d bytes, buffer is %d bytes", block, s.ID, size, ub.Size)
+		}
+	}
+	return nil
+}
+
+// Delete deletes the buffer.
+func (ub *UniformBuffer) Delete() {
+	if ub.ID != 0 {
+		gl.DeleteBuffers(1, &ub.ID)
+		ub.ID = 0
+	}
+}
+
+// BindUniformBlock links the named uniform block to the binding point,
+// it returns the data size in bytes of the block reported by the driver.
+// The binding is not carried over to the new program on hot reload.
+func (s *Shader) BindUniformBlock(block string, binding uint32) (int, error) {
+	block = strings.TrimSuffix(block, "\x00")
+	index := gl.GetUniformBlockIndex(s.ID, gl.Str(block+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return 0, fmt.Errorf("uniform block %q is not an active uniform block of the program", block)
+	}
+	gl.UniformBlockBinding(s.ID, index, binding)
+
+	var size int32
+	gl.GetActiveUniformBlockiv(s.ID, index, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+	return int(size), nil
+}
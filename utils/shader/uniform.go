@@ -0,0 +1,196 @@
+package shader
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// SetUniformVecName sets vector uniform by name, see SetUniformVec.
+func (s *Shader) SetUniformVecName(name string, vec interface{}) error {
+	location, err := s.UniformLocation(name)
+	if err != nil {
+		return err
+	}
+	return s.SetUniformVec(location, vec)
+}
+
+// SetUniformVec sets vector uniform by location.
+// The type of vec must be one of the mgl32 or mgl64 vectors, or a slice of them for arrays,
+// a slice of float32, float64, int32 or uint32 sets an array of scalars.
+func (s *Shader) SetUniformVec(uniform int32, vec interface{}) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+
+	switch v := vec.(type) {
+	case mgl32.Vec2:
+		gl.Uniform2fv(uniform, 1, &v[0])
+	case []mgl32.Vec2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform2fv(uniform, int32(len(v)), &v[0][0])
+	case mgl32.Vec3:
+		gl.Uniform3fv(uniform, 1, &v[0])
+	case []mgl32.Vec3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform3fv(uniform, int32(len(v)), &v[0][0])
+	case mgl32.Vec4:
+		gl.Uniform4fv(uniform, 1, &v[0])
+	case []mgl32.Vec4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform4fv(uniform, int32(len(v)), &v[0][0])
+	case mgl64.Vec2:
+		gl.Uniform2dv(uniform, 1, &v[0])
+	case []mgl64.Vec2:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform2dv(uniform, int32(len(v)), &v[0][0])
+	case mgl64.Vec3:
+		gl.Uniform3dv(uniform, 1, &v[0])
+	case []mgl64.Vec3:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform3dv(uniform, int32(len(v)), &v[0][0])
+	case mgl64.Vec4:
+		gl.Uniform4dv(uniform, 1, &v[0])
+	case []mgl64.Vec4:
+		if len(v) == 0 {
+			return errors.New("empty value")
+		}
+		gl.Uniform4dv(uniform, int32(len(v)), &v[0][0])
+	case []float32:
+		return s.SetUniformfv(uniform, 1, v)
+	case []float64:
+		return s.SetUniformdv(uniform, 1, v)
+	case []int32:
+		return s.SetUniformiv(uniform, 1, v)
+	case []uint32:
+		return s.SetUniformuiv(uniform, 1, v)
+	default:
+		return fmt.Errorf("unsupported vector type %T", vec)
+	}
+	return nil
+}
+
+// SetUniformfv sets uniform of float32 vectors or arrays from the flat values,
+// size is the number of components of each element, it must be 1, 2, 3 or 4,
+// the number of elements is len(v)/size.
+func (s *Shader) SetUniformfv(uniform int32, size int, v []float32) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+	if size < 1 || size > 4 {
+		return fmt.Errorf("invalid size %d", size)
+	}
+	if len(v) == 0 || len(v)%size != 0 {
+		return fmt.Errorf("invalid number of values %d for size %d", len(v), size)
+	}
+
+	count := int32(len(v) / size)
+	switch size {
+	case 1:
+		gl.Uniform1fv(uniform, count, &v[0])
+	case 2:
+		gl.Uniform2fv(uniform, count, &v[0])
+	case 3:
+		gl.Uniform3fv(uniform, count, &v[0])
+	case 4:
+		gl.Uniform4fv(uniform, count, &v[0])
+	}
+	return nil
+}
+
+// SetUniformdv sets uniform of float64 vectors or arrays from the flat values,
+// size is the number of components of each element, it must be 1, 2, 3 or 4,
+// the number of elements is len(v)/size.
+func (s *Shader) SetUniformdv(uniform int32, size int, v []float64) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+	if size < 1 || size > 4 {
+		return fmt.Errorf("invalid size %d", size)
+	}
+	if len(v) == 0 || len(v)%size != 0 {
+		return fmt.Errorf("invalid number of values %d for size %d", len(v), size)
+	}
+
+	count := int32(len(v) / size)
+	switch size {
+	case 1:
+		gl.Uniform1dv(uniform, count, &v[0])
+	case 2:
+		gl.Uniform2dv(uniform, count, &v[0])
+	case 3:
+		gl.Uniform3dv(uniform, count, &v[0])
+	case 4:
+		gl.Uniform4dv(uniform, count, &v[0])
+	}
+	return nil
+}
+
+// SetUniformiv sets uniform of int32 vectors or arrays from the flat values,
+// size is the number of components of each element, it must be 1, 2, 3 or 4,
+// the number of elements is len(v)/size.
+func (s *Shader) SetUniformiv(uniform int32, size int, v []int32) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+	if size < 1 || size > 4 {
+		return fmt.Errorf("invalid size %d", size)
+	}
+	if len(v) == 0 || len(v)%size != 0 {
+		return fmt.Errorf("invalid number of values %d for size %d", len(v), size)
+	}
+
+	count := int32(len(v) / size)
+	switch size {
+	case 1:
+		gl.Uniform1iv(uniform, count, &v[0])
+	case 2:
+		gl.Uniform2iv(uniform, count, &v[0])
+	case 3:
+		gl.Uniform3iv(uniform, count, &v[0])
+	case 4:
+		gl.Uniform4iv(uniform, count, &v[0])
+	}
+	return nil
+}
+
+// SetUniformuiv sets uniform of uint32 vectors or arrays from the flat values,
+// size is the number of components of each element, it must be 1, 2, 3 or 4,
+// the number of elements is len(v)/size.
+func (s *Shader) SetUniformuiv(uniform int32, size int, v []uint32) error {
+	if uniform < 0 {
+		return errors.New("invalid uniform")
+	}
+	if size < 1 || size > 4 {
+		return fmt.Errorf("invalid size %d", size)
+	}
+	if len(v) == 0 || len(v)%size != 0 {
+		return fmt.Errorf("invalid number of values %d for size %d", len(v), size)
+	}
+
+	count := int32(len(v) / size)
+	switch size {
+	case 1:
+		gl.Uniform1uiv(uniform, count, &v[0])
+	case 2:
+		gl.Uniform2uiv(uniform, count, &v[0])
+	case 3:
+		gl.Uniform3uiv(uniform, count, &v[0])
+	case 4:
+		gl.Uniform4uiv(uniform, count, &v[0])
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package shader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Variants is a set of shader programs compiled from the same sources with different keywords.
+// A keyword is either a boolean, which defines NAME when it is enabled,
+// or an enum of values, which defines NAME_VALUE for the selected value.
+// The variants are compiled on demand and cached by their keyword set.
+//
+//	variants := shader.NewVariants(shader.NewBuilder().
+//		File(shader.VertexStage, "4.texture.vs").
+//		File(shader.FragmentStage, "4.texture.fs"))
+//	variants.Bool("USE_MIX_RATIO").Enum("FLIP", "NONE", "HORIZONTAL")
+//
+//	variants.Enable("USE_MIX_RATIO")
+//	variants.Select("FLIP", "HORIZONTAL")
+//	shader, err := variants.Use()
+type Variants struct {
+	builder  *Builder
+	keywords map[string][]string // the values of the enum keywords, nil for the boolean keywords
+	selected map[string]string   // the selected value of each keyword, "" for disabled boolean keywords
+	variants map[string]*variant
+	err      error // the first error of declaring the keywords, returned by Shader
+}
+
+type variant struct {
+	shader *Shader
+	err    error
+}
+
+// NewVariants creates a variant set of the shader program built by the builder.
+func NewVariants(b *Builder) *Variants {
+	return &Variants{
+		builder:  b.clone(),
+		keywords: make(map[string][]string),
+		selected: make(map[string]string),
+		variants: make(map[string]*variant),
+	}
+}
+
+// Bool declares a boolean keyword, it is disabled by default.
+// A keyword defining the same macro as another keyword is an error returned by Shader.
+func (v *Variants) Bool(name string) *Variants {
+	if v.declare(name, nil) {
+		v.selected[name] = ""
+	}
+	return v
+}
+
+// Enum declares an enum keyword with its values, the first value is selected by default.
+// An enum without values, or defining the same macro as another keyword, is an error returned by Shader.
+func (v *Variants) Enum(name string, values ...string) *Variants {
+	if len(values) == 0 {
+		v.fail(fmt.Errorf("enum keyword %q has no values", name))
+		return v
+	}
+	if v.declare(name, values) {
+		v.selected[name] = values[0]
+	}
+	return v
+}
+
+// declare registers the keyword if its macros do not collide with the other keywords,
+// e.g. the boolean A_B and the enum A of the value B both define A_B.
+func (v *Variants) declare(name string, values []string) bool {
+	var names []string
+	for other := range v.keywords {
+		if other != name {
+			names = append(names, other)
+		}
+	}
+	sort.Strings(names)
+
+	for _, other := range names {
+		for _, m := range macros(name, values) {
+			for _, om := range macros(other, v.keywords[other]) {
+				if m == om {
+					v.fail(fmt.Errorf("keyword %q and keyword %q both define %s", name, other, m))
+					return false
+				}
+			}
+		}
+	}
+	v.keywords[name] = values
+	return true
+}
+
+// fail records the first error of declaring the keywords.
+func (v *Variants) fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// macros returns the macros the keyword may define.
+func macros(name string, values []string) []string {
+	if values == nil {
+		return []string{name}
+	}
+	var names []string
+	for _, value := range values {
+		names = append(names, name+"_"+value)
+	}
+	return names
+}
+
+// Enable enables the boolean keyword.
+func (v *Variants) Enable(name string) error {
+	return v.setBool(name, true)
+}
+
+// Disable disables the boolean keyword.
+func (v *Variants) Disable(name string) error {
+	return v.setBool(name, false)
+}
+
+func (v *Variants) setBool(name string, enabled bool) error {
+	values, ok := v.keywords[name]
+	if !ok {
+		return fmt.Errorf("unknown keyword %q", name)
+	}
+	if values != nil {
+		return fmt.Errorf("keyword %q is not a boolean", name)
+	}
+	v.selected[name] = ""
+	if enabled {
+		v.selected[name] = name
+	}
+	return nil
+}
+
+// Select selects the value of the enum keyword.
+func (v *Variants) Select(name, value string) error {
+	values, ok := v.keywords[name]
+	if !ok {
+		return fmt.Errorf("unknown keyword %q", name)
+	}
+	if values == nil {
+		return fmt.Errorf("keyword %q is not an enum", name)
+	}
+	for _, val := range values {
+		if val == value {
+			v.selected[name] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q of keyword %q", value, name)
+}
+
+// Shader returns the variant of the selected keywords, it is compiled if it is not in the cache.
+// A variant failing to compile is cached as well, so the error is returned without recompiling.
+func (v *Variants) Shader() (*Shader, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	key := v.key(v.selected)
+	if vr, ok := v.variants[key]; ok {
+		return vr.shader, vr.err
+	}
+
+	b := v.builder.clone()
+	for name, value := range v.defines(v.selected) {
+		b.Define(name, value)
+	}
+	shader, err := b.Build()
+	v.variants[key] = &variant{shader: shader, err: err}
+
+	return shader, err
+}
+
+// Use activates the variant of the selected keywords and returns it.
+func (v *Variants) Use() (*Shader, error) {
+	shader, err := v.Shader()
+	if err != nil {
+		return nil, err
+	}
+	shader.Use()
+	return shader, nil
+}
+
+// Warmup compiles all the permutations of the keywords, it returns the first error.
+func (v *Variants) Warmup() error {
+	if v.err != nil {
+		return v.err
+	}
+	var names []string
+	for name := range v.keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selected := v.selected
+	defer func() { v.selected = selected }()
+
+	var firstErr error
+	var permute func(i int, sel map[string]string)
+	permute = func(i int, sel map[string]string) {
+		if i == len(names) {
+			v.selected = sel
+			if _, err := v.Shader(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		name := names[i]
+		values := v.keywords[name]
+		if values == nil {
+			values = []string{"", name}
+		}
+		for _, value := range values {
+			next := make(map[string]string, len(sel)+1)
+			for k, val := range sel {
+				next[k] = val
+			}
+			next[name] = value
+			permute(i+1, next)
+		}
+	}
+	permute(0, make(map[string]string))
+
+	return firstErr
+}
+
+// defines returns the #define directives of the selected keywords.
+func (v *Variants) defines(selected map[string]string) map[string]string {
+	defines := make(map[string]string)
+	for name, value := range selected {
+		if value == "" {
+			continue
+		}
+		if v.keywords[name] == nil {
+			defines[name] = ""
+		} else {
+			defines[name+"_"+value] = ""
+		}
+	}
+	return defines
+}
+
+// key returns the cache key of the selected keywords.
+func (v *Variants) key(selected map[string]string) string {
+	var parts []string
+	for name, value := range selected {
+		if value != "" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,89 @@
+package shader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	v := NewVariants(NewBuilder()).Bool("SHADOWS").Enum("FLIP", "NONE", "HORIZONTAL").Bool("FOG")
+
+	if key := v.key(v.selected); key != "FLIP=NONE" {
+		t.Errorf("default key %q", key)
+	}
+	if defines := v.defines(v.selected); !reflect.DeepEqual(defines, map[string]string{"FLIP_NONE": ""}) {
+		t.Errorf("default defines %v", defines)
+	}
+
+	if err := v.Enable("SHADOWS"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Select("FLIP", "HORIZONTAL"); err != nil {
+		t.Fatal(err)
+	}
+	if key := v.key(v.selected); key != "FLIP=HORIZONTAL;SHADOWS=SHADOWS" {
+		t.Errorf("key %q", key)
+	}
+	want := map[string]string{"SHADOWS": "", "FLIP_HORIZONTAL": ""}
+	if defines := v.defines(v.selected); !reflect.DeepEqual(defines, want) {
+		t.Errorf("defines %v, want %v", defines, want)
+	}
+	if err := v.Disable("SHADOWS"); err != nil {
+		t.Fatal(err)
+	}
+	if key := v.key(v.selected); key != "FLIP=HORIZONTAL" {
+		t.Errorf("key %q after disabling SHADOWS", key)
+	}
+
+	errors := map[string]error{
+		"enable an enum":       v.Enable("FLIP"),
+		"enable unknown":       v.Enable("NONE"),
+		"select a boolean":     v.Select("FOG", "FOG"),
+		"select unknown":       v.Select("MODE", "A"),
+		"select invalid value": v.Select("FLIP", "VERTICAL"),
+	}
+	for name, err := range errors {
+		if err == nil {
+			t.Errorf("%s did not fail", name)
+		}
+	}
+	if key := v.key(v.selected); key != "FLIP=HORIZONTAL" {
+		t.Errorf("key %q after the failed calls", key)
+	}
+}
+
+func TestVariantsDeclare(t *testing.T) {
+	tests := []struct {
+		name    string
+		declare func(v *Variants)
+		err     string
+	}{
+		{"empty enum", func(v *Variants) { v.Enum("FLIP") }, `enum keyword "FLIP" has no values`},
+		{"bool after enum", func(v *Variants) { v.Enum("A", "B", "C").Bool("A_C") }, `keyword "A_C" and keyword "A" both define A_C`},
+		{"enum after bool", func(v *Variants) { v.Bool("A_B").Enum("A", "B") }, `keyword "A" and keyword "A_B" both define A_B`},
+		{"enums", func(v *Variants) { v.Enum("A", "B_C").Enum("A_B", "C") }, `keyword "A_B" and keyword "A" both define A_B_C`},
+		{"redeclared", func(v *Variants) { v.Bool("A").Enum("A", "B") }, ""},
+		{"distinct", func(v *Variants) { v.Bool("A").Bool("B").Enum("A_MODE", "X").Enum("C", "A") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVariants(NewBuilder())
+			tt.declare(v)
+			// the declaration errors are returned before building the program, so no GL context is needed.
+			if tt.err == "" {
+				if v.err != nil {
+					t.Errorf("error %v", v.err)
+				}
+				return
+			}
+			_, err := v.Shader()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Shader() error %v, want %q", err, tt.err)
+			}
+			if err := v.Warmup(); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Warmup() error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package shader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warnings returns the problems found in the interfaces between the stages when the program was linked:
+// the outputs not read by the next stage, the inputs not written by the previous stage and the type mismatches,
+// see CheckInterfaces.
+func (s *Shader) Warnings() []Diagnostic {
+	return s.warnings
+}
+
+// varying is an output or input variable of a stage.
+type varying struct {
+	decl Declaration
+	loc  Location
+}
+
+// key returns the name the variable is matched by,
+// the interface blocks are matched by the block name rather than the instance name.
+func (v varying) key(it *Interface) string {
+	if _, ok := it.Structs[v.decl.Type]; ok {
+		return "block " + v.decl.Type
+	}
+	return v.decl.Name
+}
+
+// CheckInterfaces compares the outputs of each stage with the inputs of the next stage of the preprocessed sources.
+// The inputs not written by the previous stage and the type or interpolation mismatches are reported as errors,
+// since the GL fails to link them if the inputs are used, the outputs not read by the next stage as warnings.
+// The built-in variables are ignored.
+func CheckInterfaces(sources map[Stage]*Source) []Diagnostic {
+	var stages []Stage
+	for _, stage := range stageOrder {
+		if _, ok := sources[stage]; ok && stage != ComputeStage {
+			stages = append(stages, stage)
+		}
+	}
+
+	var warnings []Diagnostic
+	for i := 0; i+1 < len(stages); i++ {
+		prev, next := stages[i], stages[i+1]
+		warnings = append(warnings, compareInterfaces(prev, sources[prev], next, sources[next])...)
+	}
+	return warnings
+}
+
+func compareInterfaces(prev Stage, prevSrc *Source, next Stage, nextSrc *Source) []Diagnostic {
+	prevIt := ParseInterface(prevSrc.Code)
+	nextIt := ParseInterface(nextSrc.Code)
+
+	outputs := make(map[string]varying)
+	var outputKeys []string
+	for _, d := range prevIt.Outputs {
+		if strings.HasPrefix(d.Name, "gl_") || strings.HasPrefix(d.Type, "gl_") {
+			continue
+		}
+		loc, _ := prevSrc.Locate(d.Line)
+		v := varying{decl: d, loc: loc}
+		outputs[v.key(prevIt)] = v
+		outputKeys = append(outputKeys, v.key(prevIt))
+	}
+
+	// the inputs of the tessellation and geometry stages and the outputs of the tessellation control stage
+	// are arrays of the vertices, so the array sizes are not compared for them.
+	arrayed := prev == TessControlStage || next == TessControlStage ||
+		next == TessEvaluationStage || next == GeometryStage
+
+	var warnings []Diagnostic
+	read := make(map[string]bool)
+	for _, d := range nextIt.Inputs {
+		if strings.HasPrefix(d.Name, "gl_") || strings.HasPrefix(d.Type, "gl_") {
+			continue
+		}
+		loc, _ := nextSrc.Locate(d.Line)
+		in := varying{decl: d, loc: loc}
+		key := in.key(nextIt)
+		read[key] = true
+
+		out, ok := outputs[key]
+		if !ok {
+			warnings = append(warnings, diagnostic(loc, SeverityError,
+				"%v input %s %s is not written by %v shader", next, d.TypeString(), d.Name, prev))
+			continue
+		}
+		if out.decl.Type != d.Type || (!arrayed && out.decl.Array != d.Array) {
+			warnings = append(warnings, diagnostic(loc, SeverityError,
+				"%v input %s %s does not match %v output %s %s at %v",
+				next, d.TypeString(), d.Name, prev, out.decl.TypeString(), out.decl.Name, out.loc))
+		} else if interpolation(out.decl) != interpolation(d) {
+			warnings = append(warnings, diagnostic(loc, SeverityError,
+				"%v input %s is %s, but %v output at %v is %s",
+				next, d.Name, interpolation(d), prev, out.loc, interpolation(out.decl)))
+		}
+	}
+
+	for _, key := range outputKeys {
+		if read[key] {
+			continue
+		}
+		out := outputs[key]
+		warnings = append(warnings, diagnostic(out.loc, SeverityWarning,
+			"%v output %s %s is not read by %v shader", prev, out.decl.TypeString(), out.decl.Name, next))
+	}
+
+	return warnings
+}
+
+// interpolation returns the interpolation qualifier of the variable, smooth if not specified.
+func interpolation(d Declaration) string {
+	if d.Interpolation == "" {
+		return "smooth"
+	}
+	return d.Interpolation
+}
+
+func diagnostic(loc Location, severity Severity, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{
+		File:     loc.File,
+		Line:     loc.Line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
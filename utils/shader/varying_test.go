@@ -0,0 +1,116 @@
+package shader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckInterfaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages map[Stage]string
+		want   []string // the diagnostics as "severity: message" prefixes
+	}{
+		{
+			name: "match",
+			stages: map[Stage]string{
+				VertexStage:   "out vec3 normal;\nflat out int id;\nout vec2 uv[2];\n",
+				FragmentStage: "in vec3 normal;\nflat in int id;\nin vec2 uv[2];\nout vec4 color;\n",
+			},
+		},
+		{
+			name: "type",
+			stages: map[Stage]string{
+				VertexStage:   "out vec3 normal;\n",
+				FragmentStage: "in vec4 normal;\n",
+			},
+			want: []string{"error: fragment input vec4 normal does not match vertex output vec3 normal at <source>:1"},
+		},
+		{
+			name: "array",
+			stages: map[Stage]string{
+				VertexStage:   "out vec2 uv[2];\n",
+				FragmentStage: "in vec2 uv[3];\n",
+			},
+			want: []string{"error: fragment input vec2[3] uv does not match vertex output vec2[2] uv"},
+		},
+		{
+			name: "interpolation",
+			stages: map[Stage]string{
+				VertexStage:   "flat out vec3 a;\nsmooth out vec3 b;\nout vec3 c;\n",
+				FragmentStage: "in vec3 a;\nin vec3 b;\nnoperspective in vec3 c;\n",
+			},
+			want: []string{
+				"error: fragment input a is smooth, but vertex output at <source>:1 is flat",
+				"error: fragment input c is noperspective, but vertex output at <source>:3 is smooth",
+			},
+		},
+		{
+			name: "not written and not read",
+			stages: map[Stage]string{
+				VertexStage:   "out vec3 normal;\n",
+				FragmentStage: "in vec3 tangent;\n",
+			},
+			want: []string{
+				"error: fragment input vec3 tangent is not written by vertex shader",
+				"warning: vertex output vec3 normal is not read by fragment shader",
+			},
+		},
+		{
+			name: "blocks",
+			stages: map[Stage]string{
+				VertexStage:   "out VS_OUT { vec3 normal; } vs_out;\nout Extra { float x; } extra;\n",
+				FragmentStage: "in VS_OUT { vec3 normal; } fs_in;\nin Missing { float y; } missing;\n",
+			},
+			want: []string{
+				"error: fragment input Missing missing is not written by vertex shader",
+				"warning: vertex output Extra extra is not read by fragment shader",
+			},
+		},
+		{
+			name: "builtins",
+			stages: map[Stage]string{
+				VertexStage:   "out gl_PerVertex { vec4 gl_Position; };\nout float gl_PointSize;\n",
+				FragmentStage: "in vec4 gl_FragCoord;\nout vec4 color;\n",
+			},
+		},
+		{
+			name: "geometry arrays",
+			stages: map[Stage]string{
+				VertexStage:   "out vec3 color;\n",
+				GeometryStage: "in vec3 color[];\nout vec3 gcolor;\n",
+				FragmentStage: "in vec3 gcolor;\n",
+			},
+		},
+		{
+			name: "geometry type",
+			stages: map[Stage]string{
+				VertexStage:   "out vec3 color;\n",
+				GeometryStage: "in vec4 color[];\nout vec3 gcolor;\n",
+				FragmentStage: "in vec3 gcolor;\n",
+			},
+			want: []string{"error: geometry input vec4 color does not match vertex output vec3 color"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := make(map[Stage]*Source)
+			for stage, code := range tt.stages {
+				src, err := (&Preprocessor{}).ProcessSource(code)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sources[stage] = src
+			}
+			got := CheckInterfaces(sources)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d diagnostics %v, want %d", len(got), got, len(tt.want))
+			}
+			for i, d := range got {
+				if s := d.Severity.String() + ": " + d.Message; !strings.HasPrefix(s, tt.want[i]) {
+					t.Errorf("diagnostic %d = %q, want %q", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Package std140 encodes Go values into the std140 layout of GLSL uniform blocks.
+//
+// The Go types are mapped to the GLSL types as following:
+//
+//	bool, int32, uint32, float32, float64  bool, int, uint, float, double
+//	int, uint                              int, uint (32-bit)
+//	mgl32.Vec2, mgl32.Vec3, mgl32.Vec4     vec2, vec3, vec4
+//	mgl64.Vec2, mgl64.Vec3, mgl64.Vec4     dvec2, dvec3, dvec4
+//	mgl32.MatRxC                           matCxR (mgl32 names the rows first)
+//	mgl64.MatRxC                           dmatCxR
+//	[N]T, []T                              T[N]
+//	struct                                 struct
+//
+// The struct fields are named by the glsl tag, or the field name if there is no tag.
+// The fields tagged with `glsl:"-"` and the unexported fields are skipped.
+//
+// The package does not depend on OpenGL, the buffer is in little-endian byte order.
+package std140
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field is the layout of a non-aggregate member of the block.
+type Field struct {
+	Name   string // the GLSL name, e.g. "lights[1].color"
+	Offset int    // the offset in bytes from the beginning of the block
+	Size   int    // the size in bytes, the matrix size includes the column padding
+}
+
+// Sizeof returns the size in bytes of v in std140 layout.
+func Sizeof(v interface{}) (int, error) {
+	_, size, err := layout(reflect.Indirect(reflect.ValueOf(v)))
+	return size, err
+}
+
+// Fields returns the layout of all the non-aggregate members of v in the order of offset.
+func Fields(v interface{}) ([]Field, error) {
+	var fields []Field
+	if err := walk(reflect.Indirect(reflect.ValueOf(v)), "", 0, nil, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Offsetof returns the offset in bytes of the member of v by its GLSL name, e.g. "lights[1].color".
+func Offsetof(v interface{}, name string) (int, error) {
+	fields, err := Fields(v)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range fields {
+		if f.Name == name || strings.HasPrefix(f.Name, name+".") || strings.HasPrefix(f.Name, name+"[") {
+			return f.Offset, nil
+		}
+	}
+	return 0, fmt.Errorf("std140: no member %q", name)
+}
+
+// Marshal encodes v in std140 layout, v can be a pointer to the value.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	_, size, err := layout(rv)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if err := walk(rv, "", 0, buf, nil); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// kind is the GLSL category of a Go type.
+type kind int
+
+const (
+	kindInvalid kind = iota
+	kindScalar
+	kindVector
+	kindMatrix
+	kindArray
+	kindStruct
+)
+
+func classify(t reflect.Type) kind {
+	if pkg := t.PkgPath(); strings.HasPrefix(pkg, "github.com/go-gl/mathgl/") {
+		if strings.HasPrefix(t.Name(), "Vec") {
+			return kindVector
+		}
+		if strings.HasPrefix(t.Name(), "Mat") {
+			return kindMatrix
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int32, reflect.Uint, reflect.Uint32, reflect.Float32, reflect.Float64:
+		return kindScalar
+	case reflect.Array, reflect.Slice:
+		return kindArray
+	case reflect.Struct:
+		return kindStruct
+	}
+	return kindInvalid
+}
+
+// scalarSize returns the size of the scalar type, a 64-bit scalar is a double.
+func scalarSize(t reflect.Type) int {
+	if t.Kind() == reflect.Float64 {
+		return 8
+	}
+	return 4
+}
+
+// matrixShape returns the rows and columns of the mathgl matrix type named MatN or MatRxC.
+func matrixShape(t reflect.Type) (rows, cols int) {
+	s := strings.TrimPrefix(t.Name(), "Mat")
+	if i := strings.IndexByte(s, 'x'); i >= 0 {
+		rows, _ = strconv.Atoi(s[:i])
+		cols, _ = strconv.Atoi(s[i+1:])
+		return
+	}
+	rows, _ = strconv.Atoi(s)
+	return rows, rows
+}
+
+func roundUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// vectorLayout returns the base alignment and size of a vector of n components.
+func vectorLayout(n, component int) (align, size int) {
+	if n == 2 {
+		return 2 * component, 2 * component
+	}
+	return 4 * component, n * component
+}
+
+// columnStride returns the stride of the columns of a matrix with the given rows,
+// matrices are stored as arrays of column vectors, so the stride is rounded up to vec4.
+func columnStride(rows, component int) int {
+	align, size := vectorLayout(rows, component)
+	return roundUp(size, roundUp(align, 16))
+}
+
+// layout returns the base alignment and size of v,
+// the length of slices are taken from v, so v must be valid for slices.
+func layout(v reflect.Value) (align, size int, err error) {
+	if !v.IsValid() {
+		return 0, 0, fmt.Errorf("std140: invalid value")
+	}
+	t := v.Type()
+	switch classify(t) {
+	case kindScalar:
+		n := scalarSize(t)
+		return n, n, nil
+	case kindVector:
+		align, size = vectorLayout(t.Len(), scalarSize(t.Elem()))
+		return align, size, nil
+	case kindMatrix:
+		rows, cols := matrixShape(t)
+		stride := columnStride(rows, scalarSize(t.Elem()))
+		return stride, stride * cols, nil
+	case kindArray:
+		stride, elemAlign, err := arrayStride(v)
+		if err != nil {
+			return 0, 0, err
+		}
+		return elemAlign, stride * v.Len(), nil
+	case kindStruct:
+		offset := 0
+		align = 16
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := FieldName(t.Field(i)); !ok {
+				continue
+			}
+			a, s, err := layout(v.Field(i))
+			if err != nil {
+				return 0, 0, err
+			}
+			if a > align {
+				align = roundUp(a, 16)
+			}
+			offset = roundUp(offset, a) + s
+		}
+		return align, roundUp(offset, align), nil
+	}
+	return 0, 0, fmt.Errorf("std140: unsupported type %v", t)
+}
+
+// arrayStride returns the stride and the alignment of the elements of the array v,
+// both of them are rounded up to the alignment of vec4.
+func arrayStride(v reflect.Value) (stride, align int, err error) {
+	var elem reflect.Value
+	if v.Len() > 0 {
+		elem = v.Index(0)
+	} else {
+		elem = reflect.Zero(v.Type().Elem())
+	}
+	a, s, err := layout(elem)
+	if err != nil {
+		return 0, 0, err
+	}
+	align = roundUp(a, 16)
+	return roundUp(s, align), align, nil
+}
+
+// FieldName returns the GLSL name of the struct field by the glsl tag, or the field name if there is no tag,
+// ok is false if the field is skipped.
+func FieldName(f reflect.StructField) (name string, ok bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag := strings.Split(f.Tag.Get("glsl"), ",")[0]
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		return f.Name, true
+	}
+	return tag, true
+}
+
+// walk visits v at the offset, it writes the encoded values into buf if buf is not nil,
+// and appends the non-aggregate members to fields if fields is not nil.
+func walk(v reflect.Value, name string, offset int, buf []byte, fields *[]Field) error {
+	if !v.IsValid() {
+		return fmt.Errorf("std140: invalid value")
+	}
+	t := v.Type()
+	switch classify(t) {
+	case kindScalar:
+		n := scalarSize(t)
+		if buf != nil {
+			putScalar(buf[offset:], v)
+		}
+		if fields != nil {
+			*fields = append(*fields, Field{Name: name, Offset: offset, Size: n})
+		}
+	case kindVector:
+		n := scalarSize(t.Elem())
+		if buf != nil {
+			for i := 0; i < v.Len(); i++ {
+				putScalar(buf[offset+i*n:], v.Index(i))
+			}
+		}
+		if fields != nil {
+			*fields = append(*fields, Field{Name: name, Offset: offset, Size: n * v.Len()})
+		}
+	case kindMatrix:
+		rows, cols := matrixShape(t)
+		n := scalarSize(t.Elem())
+		stride := columnStride(rows, n)
+		if buf != nil {
+			// mathgl matrices are column major as well.
+			for c := 0; c < cols; c++ {
+				for r := 0; r < rows; r++ {
+					putScalar(buf[offset+c*stride+r*n:], v.Index(c*rows+r))
+				}
+			}
+		}
+		if fields != nil {
+			*fields = append(*fields, Field{Name: name, Offset: offset, Size: stride * cols})
+		}
+	case kindArray:
+		stride, _, err := arrayStride(v)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := walk(v.Index(i), fmt.Sprintf("%s[%d]", name, i), offset+i*stride, buf, fields); err != nil {
+				return err
+			}
+		}
+	case kindStruct:
+		off := offset
+		for i := 0; i < t.NumField(); i++ {
+			fname, ok := FieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			if name != "" {
+				fname = name + "." + fname
+			}
+			a, s, err := layout(v.Field(i))
+			if err != nil {
+				return err
+			}
+			off = roundUp(off, a)
+			if err := walk(v.Field(i), fname, off, buf, fields); err != nil {
+				return err
+			}
+			off += s
+		}
+	default:
+		return fmt.Errorf("std140: unsupported type %v", t)
+	}
+	return nil
+}
+
+func putScalar(b []byte, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			binary.LittleEndian.PutUint32(b, 1)
+		} else {
+			binary.LittleEndian.PutUint32(b, 0)
+		}
+	case reflect.Int, reflect.Int32:
+		binary.LittleEndian.PutUint32(b, uint32(int32(v.Int())))
+	case reflect.Uint, reflect.Uint32:
+		binary.LittleEndian.PutUint32(b, uint32(v.Uint()))
+	case reflect.Float32:
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v.Float()))
+	}
+}
@@ -0,0 +1,132 @@
+package std140
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+type light struct {
+	Color mgl32.Vec3
+	Pos   mgl32.Vec2 `glsl:"position"`
+}
+
+// encode returns a buffer of the size with the values at the offsets, the float64 values are doubles.
+func encode(size int, values ...interface{}) []byte {
+	b := make([]byte, size)
+	for i := 0; i < len(values); i += 2 {
+		off := values[i].(int)
+		switch v := values[i+1].(type) {
+		case float32:
+			binary.LittleEndian.PutUint32(b[off:], math.Float32bits(v))
+		case float64:
+			binary.LittleEndian.PutUint64(b[off:], math.Float64bits(v))
+		}
+	}
+	return b
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		fields []Field
+		data   []byte
+	}{
+		{
+			name: "float after vec3",
+			value: struct {
+				V mgl32.Vec3
+				F float32
+			}{mgl32.Vec3{1, 2, 3}, 4},
+			fields: []Field{{"V", 0, 12}, {"F", 12, 4}},
+			data:   encode(16, 0, float32(1), 4, float32(2), 8, float32(3), 12, float32(4)),
+		},
+		{
+			name: "float array",
+			value: struct {
+				A [3]float32
+			}{[3]float32{1, 2, 3}},
+			fields: []Field{{"A[0]", 0, 4}, {"A[1]", 16, 4}, {"A[2]", 32, 4}},
+			data:   encode(48, 0, float32(1), 16, float32(2), 32, float32(3)),
+		},
+		{
+			name: "mat3",
+			value: struct {
+				M mgl32.Mat3
+			}{mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+			fields: []Field{{"M", 0, 48}},
+			data: encode(48,
+				0, float32(1), 4, float32(2), 8, float32(3),
+				16, float32(4), 20, float32(5), 24, float32(6),
+				32, float32(7), 36, float32(8), 40, float32(9)),
+		},
+		{
+			name: "dvec3 array",
+			value: struct {
+				D [2]mgl64.Vec3
+				F float32
+			}{[2]mgl64.Vec3{{1, 2, 3}, {4, 5, 6}}, 7},
+			fields: []Field{{"D[0]", 0, 24}, {"D[1]", 32, 24}, {"F", 64, 4}},
+			data: encode(96,
+				0, 1.0, 8, 2.0, 16, 3.0,
+				32, 4.0, 40, 5.0, 48, 6.0,
+				64, float32(7)),
+		},
+		{
+			name: "nested struct",
+			value: struct {
+				F float32
+				S struct{ X float32 }
+				G float32
+			}{1, struct{ X float32 }{2}, 3},
+			fields: []Field{{"F", 0, 4}, {"S.X", 16, 4}, {"G", 32, 4}},
+			data:   encode(48, 0, float32(1), 16, float32(2), 32, float32(3)),
+		},
+		{
+			name: "struct array",
+			value: struct {
+				Lights []light `glsl:"lights"`
+			}{[]light{{mgl32.Vec3{1, 2, 3}, mgl32.Vec2{4, 5}}, {mgl32.Vec3{6, 7, 8}, mgl32.Vec2{9, 10}}}},
+			fields: []Field{
+				{"lights[0].Color", 0, 12}, {"lights[0].position", 16, 8},
+				{"lights[1].Color", 32, 12}, {"lights[1].position", 48, 8},
+			},
+			data: encode(64,
+				0, float32(1), 4, float32(2), 8, float32(3), 16, float32(4), 20, float32(5),
+				32, float32(6), 36, float32(7), 40, float32(8), 48, float32(9), 52, float32(10)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := Sizeof(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != len(tt.data) {
+				t.Errorf("Sizeof = %d, want %d", size, len(tt.data))
+			}
+
+			fields, err := Fields(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Fields = %v, want %v", fields, tt.fields)
+			}
+
+			data, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("Marshal = %v, want %v", data, tt.data)
+			}
+		})
+	}
+}
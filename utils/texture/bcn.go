@@ -0,0 +1,649 @@
+package texture
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The decoders of the BCn blocks, each decodes a 4x4 block into 16 pixels of the fallback format of the block,
+// the pixels are row by row.
+
+// decodeBC1 decodes a BC1 (DXT1) block into RGBA8 pixels,
+// if alpha is true the color index 3 of the 3-color blocks is transparent black.
+func decodeBC1(block, out []byte, alpha bool) {
+	decodeBC1Color(block, out, alpha, false)
+}
+
+// decodeBC2 decodes a BC2 (DXT3) block into RGBA8 pixels.
+func decodeBC2(block, out []byte) {
+	decodeBC1Color(block[8:], out, false, true)
+	alpha := binary.LittleEndian.Uint64(block)
+	for i := 0; i < 16; i++ {
+		out[i*4+3] = byte(alpha>>(4*uint(i))&0xf) * 17
+	}
+}
+
+// decodeBC3 decodes a BC3 (DXT5) block into RGBA8 pixels.
+func decodeBC3(block, out []byte) {
+	decodeBC1Color(block[8:], out, false, true)
+	decodeBC4Unorm(block, out[3:], 4)
+}
+
+// decodeBC1Color decodes the color part of the BC1, BC2 and BC3 blocks.
+// The blocks of BC2 and BC3 are always decoded in the 4-color mode.
+func decodeBC1Color(block, out []byte, alpha, fourColors bool) {
+	c0 := binary.LittleEndian.Uint16(block)
+	c1 := binary.LittleEndian.Uint16(block[2:])
+	indices := binary.LittleEndian.Uint32(block[4:])
+
+	var colors [4][4]int
+	colors[0] = rgb565(c0)
+	colors[1] = rgb565(c1)
+	if c0 > c1 || fourColors {
+		for c := 0; c < 3; c++ {
+			colors[2][c] = (2*colors[0][c] + colors[1][c]) / 3
+			colors[3][c] = (colors[0][c] + 2*colors[1][c]) / 3
+		}
+		colors[2][3], colors[3][3] = 255, 255
+	} else {
+		for c := 0; c < 3; c++ {
+			colors[2][c] = (colors[0][c] + colors[1][c]) / 2
+		}
+		colors[2][3], colors[3][3] = 255, 255
+		if alpha {
+			colors[3][3] = 0
+		}
+	}
+
+	for i := 0; i < 16; i++ {
+		color := colors[indices>>(2*uint(i))&3]
+		for c := 0; c < 4; c++ {
+			out[i*4+c] = byte(color[c])
+		}
+	}
+}
+
+func rgb565(c uint16) [4]int {
+	r := int(c>>11) & 0x1f
+	g := int(c>>5) & 0x3f
+	b := int(c) & 0x1f
+	return [4]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+// decodeBC4Unorm decodes an unsigned BC4 block, which is also the alpha block of BC3,
+// into the 8-bit components of out, the components of two pixels are stride bytes apart.
+func decodeBC4Unorm(block, out []byte, stride int) {
+	var values [8]int
+	values[0], values[1] = int(block[0]), int(block[1])
+	interpolateBC4(&values, 0, 255)
+
+	indices := uint64(block[2]) | uint64(block[3])<<8 | uint64(block[4])<<16 |
+		uint64(block[5])<<24 | uint64(block[6])<<32 | uint64(block[7])<<40
+	for i := 0; i < 16; i++ {
+		out[i*stride] = byte(values[indices>>(3*uint(i))&7])
+	}
+}
+
+// decodeBC4Snorm decodes a signed BC4 block into the 8-bit signed components of out.
+func decodeBC4Snorm(block, out []byte, stride int) {
+	var values [8]int
+	values[0], values[1] = int(int8(block[0])), int(int8(block[1]))
+	for i := 0; i < 2; i++ {
+		if values[i] == -128 {
+			values[i] = -127
+		}
+	}
+	interpolateBC4(&values, -127, 127)
+
+	indices := uint64(block[2]) | uint64(block[3])<<8 | uint64(block[4])<<16 |
+		uint64(block[5])<<24 | uint64(block[6])<<32 | uint64(block[7])<<40
+	for i := 0; i < 16; i++ {
+		out[i*stride] = byte(int8(values[indices>>(3*uint(i))&7]))
+	}
+}
+
+// interpolateBC4 fills the values interpolated between the two endpoints of a BC4 block.
+func interpolateBC4(values *[8]int, min, max int) {
+	v0, v1 := values[0], values[1]
+	if v0 > v1 {
+		for i := 1; i < 7; i++ {
+			values[i+1] = ((7-i)*v0 + i*v1) / 7
+		}
+		return
+	}
+	for i := 1; i < 5; i++ {
+		values[i+1] = ((5-i)*v0 + i*v1) / 5
+	}
+	values[6], values[7] = min, max
+}
+
+// decodeBC5Unorm decodes an unsigned BC5 block into RG8 pixels.
+func decodeBC5Unorm(block, out []byte) {
+	decodeBC4Unorm(block, out, 2)
+	decodeBC4Unorm(block[8:], out[1:], 2)
+}
+
+// decodeBC5Snorm decodes a signed BC5 block into signed RG8 pixels.
+func decodeBC5Snorm(block, out []byte) {
+	decodeBC4Snorm(block, out, 2)
+	decodeBC4Snorm(block[8:], out[1:], 2)
+}
+
+// bc7Mode is the layout of a BC7 block mode.
+type bc7Mode struct {
+	subsets        int
+	partitionBits  uint
+	rotationBits   uint
+	indexSelection uint
+	colorBits      uint
+	alphaBits      uint
+	endpointPBits  bool // a p-bit for each endpoint
+	sharedPBits    bool // a p-bit for each subset
+	indexBits      uint
+	indexBits2     uint // the bits of the secondary indices
+}
+
+var bc7Modes = [8]bc7Mode{
+	{3, 4, 0, 0, 4, 0, true, false, 3, 0},
+	{2, 6, 0, 0, 6, 0, false, true, 3, 0},
+	{3, 6, 0, 0, 5, 0, false, false, 2, 0},
+	{2, 6, 0, 0, 7, 0, true, false, 2, 0},
+	{1, 0, 2, 1, 5, 6, false, false, 2, 3},
+	{1, 0, 2, 0, 7, 8, false, false, 2, 2},
+	{1, 0, 0, 0, 7, 7, true, false, 4, 0},
+	{2, 6, 0, 0, 5, 5, true, false, 2, 0},
+}
+
+// bc7Partitions2 are the partitions of the 2-subset blocks, the bit i is the subset of the pixel i.
+// They are shared with BC6H.
+var bc7Partitions2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// bc7Partitions3 are the partitions of the 3-subset blocks, the subsets of the pixels row by row.
+var bc7Partitions3 = [64][16]byte{
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 1, 2, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 2, 0, 0, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 1, 0, 1, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2},
+	{0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0, 2, 2, 2, 0},
+	{0, 0, 0, 1, 0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2},
+	{0, 1, 1, 1, 0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0},
+	{0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1},
+	{0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2, 0, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 0, 1, 2, 2, 2, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 0, 0, 1, 1, 0, 0, 2, 2, 1, 0, 2, 2, 1, 0},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1, 0, 0, 0, 0},
+	{0, 0, 1, 2, 0, 0, 1, 2, 1, 1, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1, 0, 1, 1, 0},
+	{0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1},
+	{0, 0, 2, 2, 1, 1, 0, 2, 1, 1, 0, 2, 0, 0, 2, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 0, 0, 2, 2, 2, 2, 2},
+	{0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 0, 0, 2, 0, 0, 0, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 2, 0, 0, 2, 2, 0, 2, 2, 2},
+	{0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0},
+	{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0},
+	{0, 1, 2, 0, 2, 0, 1, 2, 1, 2, 0, 1, 0, 1, 2, 0},
+	{0, 0, 1, 1, 2, 2, 0, 0, 1, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0, 1, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 0, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 1, 1},
+	{0, 2, 2, 0, 1, 2, 2, 1, 0, 2, 2, 0, 1, 2, 2, 1},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 0, 1, 0, 1},
+	{0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 2, 2, 2, 0, 1, 1, 1},
+	{0, 0, 0, 2, 1, 1, 1, 2, 0, 0, 0, 2, 1, 1, 1, 2},
+	{0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2},
+	{0, 0, 0, 2, 1, 1, 1, 2, 1, 1, 1, 2, 0, 0, 0, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2},
+	{0, 0, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2},
+	{0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 1},
+	{0, 2, 2, 2, 1, 2, 2, 2, 0, 2, 2, 2, 1, 2, 2, 2},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 1, 2, 0, 1, 1, 2, 2, 0, 1, 2, 2, 2, 0},
+}
+
+// the anchor pixels of the second subset of the 2-subset blocks,
+// and of the second and the third subsets of the 3-subset blocks, the first subset is anchored at pixel 0.
+var (
+	bc7Anchors2 = [64]byte{
+		15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+		15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+		15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+		6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+	}
+	bc7Anchors3Second = [64]byte{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	}
+	bc7Anchors3Third = [64]byte{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	}
+)
+
+// the interpolation weights of the 2, 3 and 4-bit indices.
+var bc7Weights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bitReader reads the bits of a 128-bit block from the least significant bit.
+type bitReader struct {
+	lo, hi uint64
+	pos    uint
+}
+
+func newBitReader(block []byte) *bitReader {
+	return &bitReader{lo: binary.LittleEndian.Uint64(block), hi: binary.LittleEndian.Uint64(block[8:])}
+}
+
+func (r *bitReader) read(n uint) int {
+	v := 0
+	for i := uint(0); i < n; i++ {
+		var bit uint64
+		if r.pos < 64 {
+			bit = r.lo >> r.pos & 1
+		} else {
+			bit = r.hi >> (r.pos - 64) & 1
+		}
+		v |= int(bit) << i
+		r.pos++
+	}
+	return v
+}
+
+// decodeBC7 decodes a BC7 block into RGBA8 pixels.
+func decodeBC7(block, out []byte) {
+	mode := 0
+	for mode < 8 && block[0]&(1<<uint(mode)) == 0 {
+		mode++
+	}
+	if mode == 8 {
+		// the reserved mode decodes to transparent black.
+		for i := range out[:64] {
+			out[i] = 0
+		}
+		return
+	}
+
+	m := bc7Modes[mode]
+	r := newBitReader(block)
+	r.pos = uint(mode) + 1
+	partition := r.read(m.partitionBits)
+	rotation := r.read(m.rotationBits)
+	indexSelection := r.read(m.indexSelection)
+
+	var endpoints [6][4]int
+	n := m.subsets * 2
+	for c := 0; c < 3; c++ {
+		for e := 0; e < n; e++ {
+			endpoints[e][c] = r.read(m.colorBits)
+		}
+	}
+	for e := 0; e < n && m.alphaBits > 0; e++ {
+		endpoints[e][3] = r.read(m.alphaBits)
+	}
+
+	var pbits [6]int
+	switch {
+	case m.endpointPBits:
+		for e := 0; e < n; e++ {
+			pbits[e] = r.read(1)
+		}
+	case m.sharedPBits:
+		for s := 0; s < m.subsets; s++ {
+			pbits[2*s] = r.read(1)
+			pbits[2*s+1] = pbits[2*s]
+		}
+	}
+
+	for e := 0; e < n; e++ {
+		for c := 0; c < 4; c++ {
+			bits := m.colorBits
+			if c == 3 {
+				bits = m.alphaBits
+			}
+			if bits == 0 {
+				endpoints[e][c] = 255
+				continue
+			}
+			v := endpoints[e][c]
+			if m.endpointPBits || m.sharedPBits {
+				v = v<<1 | pbits[e]
+				bits++
+			}
+			v <<= 8 - bits
+			endpoints[e][c] = v | v>>bits
+		}
+	}
+
+	// the subset and whether it is an anchor of each pixel.
+	var subsets [16]int
+	var anchors [16]bool
+	anchors[0] = true
+	for i := 0; i < 16; i++ {
+		switch m.subsets {
+		case 2:
+			subsets[i] = int(bc7Partitions2[partition] >> uint(i) & 1)
+		case 3:
+			subsets[i] = int(bc7Partitions3[partition][i])
+		}
+	}
+	switch m.subsets {
+	case 2:
+		anchors[bc7Anchors2[partition]] = true
+	case 3:
+		anchors[bc7Anchors3Second[partition]] = true
+		anchors[bc7Anchors3Third[partition]] = true
+	}
+
+	var indices, indices2 [16]int
+	for i := 0; i < 16; i++ {
+		bits := m.indexBits
+		if anchors[i] {
+			bits--
+		}
+		indices[i] = r.read(bits)
+	}
+	for i := 0; i < 16 && m.indexBits2 > 0; i++ {
+		bits := m.indexBits2
+		if i == 0 {
+			bits--
+		}
+		indices2[i] = r.read(bits)
+	}
+
+	for i := 0; i < 16; i++ {
+		e0, e1 := endpoints[2*subsets[i]], endpoints[2*subsets[i]+1]
+		colorIndex, colorBits := indices[i], m.indexBits
+		alphaIndex, alphaBits := indices[i], m.indexBits
+		if m.indexBits2 > 0 {
+			if indexSelection == 0 {
+				alphaIndex, alphaBits = indices2[i], m.indexBits2
+			} else {
+				colorIndex, colorBits = indices2[i], m.indexBits2
+			}
+		}
+
+		var pixel [4]int
+		for c := 0; c < 4; c++ {
+			index, bits := colorIndex, colorBits
+			if c == 3 {
+				index, bits = alphaIndex, alphaBits
+			}
+			w := bc7Weights[bits][index]
+			pixel[c] = ((64-w)*e0[c] + w*e1[c] + 32) >> 6
+		}
+		switch rotation {
+		case 1:
+			pixel[0], pixel[3] = pixel[3], pixel[0]
+		case 2:
+			pixel[1], pixel[3] = pixel[3], pixel[1]
+		case 3:
+			pixel[2], pixel[3] = pixel[3], pixel[2]
+		}
+		for c := 0; c < 4; c++ {
+			out[i*4+c] = byte(pixel[c])
+		}
+	}
+}
+
+// bc6hField is a run of the bits of an endpoint component in a BC6H block,
+// the endpoints are w, x, y and z, the channels are red, green and blue.
+type bc6hField struct {
+	endpoint, channel int
+	shift, bits       uint
+}
+
+// bc6hMode is the layout of a BC6H block mode.
+type bc6hMode struct {
+	subsets      int
+	transformed  bool    // the endpoints but w are stored as deltas from w
+	endpointBits uint    // the bits of the endpoint w
+	deltaBits    [3]uint // the bits of the other endpoints of each channel
+	fields       []bc6hField
+}
+
+// bc6hModes are the modes by their 2-bit, or 5-bit if the low 2 bits are above 1, mode numbers.
+// The fields are in the order of the bits after the mode, the reversed bits are listed one by one.
+var bc6hModes = map[int]*bc6hMode{
+	0x00: {2, true, 10, [3]uint{5, 5, 5}, []bc6hField{
+		{2, 1, 4, 1}, {2, 2, 4, 1}, {3, 2, 4, 1}, {0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10},
+		{1, 0, 0, 5}, {3, 1, 4, 1}, {2, 1, 0, 4}, {1, 1, 0, 5}, {3, 2, 0, 1}, {3, 1, 0, 4},
+		{1, 2, 0, 5}, {3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 5}, {3, 2, 2, 1}, {3, 0, 0, 5}, {3, 2, 3, 1},
+	}},
+	0x01: {2, true, 7, [3]uint{6, 6, 6}, []bc6hField{
+		{2, 1, 5, 1}, {3, 1, 4, 1}, {3, 1, 5, 1}, {0, 0, 0, 7}, {3, 2, 0, 1}, {3, 2, 1, 1},
+		{2, 2, 4, 1}, {0, 1, 0, 7}, {2, 2, 5, 1}, {3, 2, 2, 1}, {2, 1, 4, 1}, {0, 2, 0, 7},
+		{3, 2, 3, 1}, {3, 2, 5, 1}, {3, 2, 4, 1}, {1, 0, 0, 6}, {2, 1, 0, 4}, {1, 1, 0, 6},
+		{3, 1, 0, 4}, {1, 2, 0, 6}, {2, 2, 0, 4}, {2, 0, 0, 6}, {3, 0, 0, 6},
+	}},
+	0x02: {2, true, 11, [3]uint{5, 4, 4}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10}, {1, 0, 0, 5}, {0, 0, 10, 1}, {2, 1, 0, 4},
+		{1, 1, 0, 4}, {0, 1, 10, 1}, {3, 2, 0, 1}, {3, 1, 0, 4}, {1, 2, 0, 4}, {0, 2, 10, 1},
+		{3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 5}, {3, 2, 2, 1}, {3, 0, 0, 5}, {3, 2, 3, 1},
+	}},
+	0x06: {2, true, 11, [3]uint{4, 5, 4}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10}, {1, 0, 0, 4}, {0, 0, 10, 1}, {3, 1, 4, 1},
+		{2, 1, 0, 4}, {1, 1, 0, 5}, {0, 1, 10, 1}, {3, 1, 0, 4}, {1, 2, 0, 4}, {0, 2, 10, 1},
+		{3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 4}, {3, 2, 0, 1}, {3, 2, 2, 1}, {3, 0, 0, 4},
+		{2, 1, 4, 1}, {3, 2, 3, 1},
+	}},
+	0x0a: {2, true, 11, [3]uint{4, 4, 5}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10}, {1, 0, 0, 4}, {0, 0, 10, 1}, {2, 2, 4, 1},
+		{2, 1, 0, 4}, {1, 1, 0, 4}, {0, 1, 10, 1}, {3, 2, 0, 1}, {3, 1, 0, 4}, {1, 2, 0, 5},
+		{0, 2, 10, 1}, {2, 2, 0, 4}, {2, 0, 0, 4}, {3, 2, 1, 1}, {3, 2, 2, 1}, {3, 0, 0, 4},
+		{3, 2, 4, 1}, {3, 2, 3, 1},
+	}},
+	0x0e: {2, true, 9, [3]uint{5, 5, 5}, []bc6hField{
+		{0, 0, 0, 9}, {2, 2, 4, 1}, {0, 1, 0, 9}, {2, 1, 4, 1}, {0, 2, 0, 9}, {3, 2, 4, 1},
+		{1, 0, 0, 5}, {3, 1, 4, 1}, {2, 1, 0, 4}, {1, 1, 0, 5}, {3, 2, 0, 1}, {3, 1, 0, 4},
+		{1, 2, 0, 5}, {3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 5}, {3, 2, 2, 1}, {3, 0, 0, 5}, {3, 2, 3, 1},
+	}},
+	0x12: {2, true, 8, [3]uint{6, 5, 5}, []bc6hField{
+		{0, 0, 0, 8}, {3, 1, 4, 1}, {2, 2, 4, 1}, {0, 1, 0, 8}, {3, 2, 2, 1}, {2, 1, 4, 1},
+		{0, 2, 0, 8}, {3, 2, 3, 1}, {3, 2, 4, 1}, {1, 0, 0, 6}, {2, 1, 0, 4}, {1, 1, 0, 5},
+		{3, 2, 0, 1}, {3, 1, 0, 4}, {1, 2, 0, 5}, {3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 6}, {3, 0, 0, 6},
+	}},
+	0x16: {2, true, 8, [3]uint{5, 6, 5}, []bc6hField{
+		{0, 0, 0, 8}, {3, 2, 0, 1}, {2, 2, 4, 1}, {0, 1, 0, 8}, {2, 1, 5, 1}, {2, 1, 4, 1},
+		{0, 2, 0, 8}, {3, 1, 5, 1}, {3, 2, 4, 1}, {1, 0, 0, 5}, {3, 1, 4, 1}, {2, 1, 0, 4},
+		{1, 1, 0, 6}, {3, 1, 0, 4}, {1, 2, 0, 5}, {3, 2, 1, 1}, {2, 2, 0, 4}, {2, 0, 0, 5},
+		{3, 2, 2, 1}, {3, 0, 0, 5}, {3, 2, 3, 1},
+	}},
+	0x1a: {2, true, 8, [3]uint{5, 5, 6}, []bc6hField{
+		{0, 0, 0, 8}, {3, 2, 1, 1}, {2, 2, 4, 1}, {0, 1, 0, 8}, {2, 2, 5, 1}, {2, 1, 4, 1},
+		{0, 2, 0, 8}, {3, 2, 5, 1}, {3, 2, 4, 1}, {1, 0, 0, 5}, {3, 1, 4, 1}, {2, 1, 0, 4},
+		{1, 1, 0, 5}, {3, 2, 0, 1}, {3, 1, 0, 4}, {1, 2, 0, 6}, {2, 2, 0, 4}, {2, 0, 0, 5},
+		{3, 2, 2, 1}, {3, 0, 0, 5}, {3, 2, 3, 1},
+	}},
+	0x1e: {2, false, 6, [3]uint{6, 6, 6}, []bc6hField{
+		{0, 0, 0, 6}, {3, 1, 4, 1}, {3, 2, 0, 1}, {3, 2, 1, 1}, {2, 2, 4, 1}, {0, 1, 0, 6},
+		{2, 1, 5, 1}, {2, 2, 5, 1}, {3, 2, 2, 1}, {2, 1, 4, 1}, {0, 2, 0, 6}, {3, 1, 5, 1},
+		{3, 2, 3, 1}, {3, 2, 5, 1}, {3, 2, 4, 1}, {1, 0, 0, 6}, {2, 1, 0, 4}, {1, 1, 0, 6},
+		{3, 1, 0, 4}, {1, 2, 0, 6}, {2, 2, 0, 4}, {2, 0, 0, 6}, {3, 0, 0, 6},
+	}},
+	0x03: {1, false, 10, [3]uint{10, 10, 10}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10}, {1, 0, 0, 10}, {1, 1, 0, 10}, {1, 2, 0, 10},
+	}},
+	0x07: {1, true, 11, [3]uint{9, 9, 9}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10},
+		{1, 0, 0, 9}, {0, 0, 10, 1}, {1, 1, 0, 9}, {0, 1, 10, 1}, {1, 2, 0, 9}, {0, 2, 10, 1},
+	}},
+	0x0b: {1, true, 12, [3]uint{8, 8, 8}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10},
+		{1, 0, 0, 8}, {0, 0, 11, 1}, {0, 0, 10, 1},
+		{1, 1, 0, 8}, {0, 1, 11, 1}, {0, 1, 10, 1},
+		{1, 2, 0, 8}, {0, 2, 11, 1}, {0, 2, 10, 1},
+	}},
+	0x0f: {1, true, 16, [3]uint{4, 4, 4}, []bc6hField{
+		{0, 0, 0, 10}, {0, 1, 0, 10}, {0, 2, 0, 10},
+		{1, 0, 0, 4}, {0, 0, 15, 1}, {0, 0, 14, 1}, {0, 0, 13, 1}, {0, 0, 12, 1}, {0, 0, 11, 1}, {0, 0, 10, 1},
+		{1, 1, 0, 4}, {0, 1, 15, 1}, {0, 1, 14, 1}, {0, 1, 13, 1}, {0, 1, 12, 1}, {0, 1, 11, 1}, {0, 1, 10, 1},
+		{1, 2, 0, 4}, {0, 2, 15, 1}, {0, 2, 14, 1}, {0, 2, 13, 1}, {0, 2, 12, 1}, {0, 2, 11, 1}, {0, 2, 10, 1},
+	}},
+}
+
+// decodeBC6H decodes a BC6H block into RGB16F pixels, which are float32 components in big endian as of the pixels.
+func decodeBC6H(block, out []byte, signed bool) {
+	r := newBitReader(block)
+	mode := r.read(2)
+	if mode > 1 {
+		mode |= r.read(3) << 2
+	}
+	m, ok := bc6hModes[mode]
+	if !ok {
+		// the reserved modes decode to black.
+		for i := range out[:16*12] {
+			out[i] = 0
+		}
+		return
+	}
+
+	var endpoints [4][3]int
+	for _, f := range m.fields {
+		endpoints[f.endpoint][f.channel] |= r.read(f.bits) << f.shift
+	}
+	partition := 0
+	if m.subsets == 2 {
+		partition = r.read(5)
+	}
+
+	n := m.subsets * 2
+	for c := 0; c < 3; c++ {
+		w := endpoints[0][c]
+		if signed {
+			w = signExtend(w, m.endpointBits)
+		}
+		endpoints[0][c] = w
+		for e := 1; e < n; e++ {
+			v := endpoints[e][c]
+			if m.transformed || signed {
+				v = signExtend(v, m.deltaBits[c])
+			}
+			if m.transformed {
+				v = (w + v) & (1<<m.endpointBits - 1)
+				if signed {
+					v = signExtend(v, m.endpointBits)
+				}
+			}
+			endpoints[e][c] = v
+		}
+		for e := 0; e < n; e++ {
+			endpoints[e][c] = unquantizeBC6H(endpoints[e][c], m.endpointBits, signed)
+		}
+	}
+
+	indexBits := uint(4)
+	if m.subsets == 2 {
+		indexBits = 3
+	}
+	weights := bc7Weights[indexBits]
+	for i := 0; i < 16; i++ {
+		subset := 0
+		bits := indexBits
+		if m.subsets == 2 {
+			subset = int(bc7Partitions2[partition] >> uint(i) & 1)
+			if i == int(bc7Anchors2[partition]) {
+				bits--
+			}
+		}
+		if i == 0 {
+			bits--
+		}
+		w := weights[r.read(bits)]
+		e0, e1 := endpoints[2*subset], endpoints[2*subset+1]
+		for c := 0; c < 3; c++ {
+			v := ((64-w)*e0[c] + w*e1[c] + 32) >> 6
+			h := halfToFloat(finishBC6H(v, signed))
+			binary.BigEndian.PutUint32(out[(i*3+c)*4:], math.Float32bits(h))
+		}
+	}
+}
+
+func signExtend(v int, bits uint) int {
+	return int(int32(v<<(32-bits)) >> (32 - bits))
+}
+
+// unquantizeBC6H expands the endpoint component of the bits to 16 bits.
+func unquantizeBC6H(v int, bits uint, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15:
+			return v
+		case v == 0:
+			return 0
+		case v == 1<<bits-1:
+			return 0xffff
+		}
+		return (v<<16 + 0x8000) >> bits
+	}
+
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<(bits-1)-1:
+		v = 0x7fff
+	default:
+		v = (v<<15 + 0x4000) >> (bits - 1)
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// finishBC6H scales the interpolated component to the bits of a half float.
+func finishBC6H(v int, signed bool) uint16 {
+	if !signed {
+		return uint16(v * 31 >> 6)
+	}
+	if v < 0 {
+		return 0x8000 | uint16(-v*31>>5)
+	}
+	return uint16(v * 31 >> 5)
+}
@@ -0,0 +1,406 @@
+package texture
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// bitWriter writes the bits of a 128-bit block from the least significant bit, as bitReader reads them.
+type bitWriter struct {
+	block [16]byte
+	pos   uint
+}
+
+func (w *bitWriter) write(v int, n uint) {
+	for i := uint(0); i < n; i++ {
+		if v>>i&1 != 0 {
+			w.block[w.pos/8] |= 1 << (w.pos % 8)
+		}
+		w.pos++
+	}
+}
+
+func TestBC6HModes(t *testing.T) {
+	for mode, m := range bc6hModes {
+		modeBits := uint(5)
+		if mode < 2 {
+			modeBits = 2
+		}
+		var covered [4][3]uint64 // the bits of each endpoint component
+		total := modeBits
+		for _, f := range m.fields {
+			for b := f.shift; b < f.shift+f.bits; b++ {
+				if covered[f.endpoint][f.channel]&(1<<b) != 0 {
+					t.Errorf("mode 0x%02x: bit %d of endpoint %d channel %d is read twice", mode, b, f.endpoint, f.channel)
+				}
+				covered[f.endpoint][f.channel] |= 1 << b
+			}
+			total += f.bits
+		}
+		for e := 0; e < m.subsets*2; e++ {
+			for c := 0; c < 3; c++ {
+				bits := m.deltaBits[c]
+				if e == 0 {
+					bits = m.endpointBits
+				}
+				if covered[e][c] != 1<<bits-1 {
+					t.Errorf("mode 0x%02x: endpoint %d channel %d has bits %b, expect %d bits", mode, e, c, covered[e][c], bits)
+				}
+			}
+		}
+		// the 2-subset blocks have 5 partition bits and 46 index bits, the 1-subset blocks 63 index bits.
+		want := uint(128 - 63)
+		if m.subsets == 2 {
+			want = 128 - 46 - 5
+		}
+		if total != want {
+			t.Errorf("mode 0x%02x: %d bits before the indices, expect %d", mode, total, want)
+		}
+	}
+}
+
+func TestDecodeBC6H(t *testing.T) {
+	pixel := func(out []byte, i int) [3]float32 {
+		var p [3]float32
+		for c := range p {
+			p[c] = math.Float32frombits(binary.BigEndian.Uint32(out[(i*3+c)*4:]))
+		}
+		return p
+	}
+	out := make([]byte, 16*12)
+
+	// mode 0x03, 10-bit endpoints without deltas, black to white.
+	var w bitWriter
+	w.write(0x03, 5)
+	for _, v := range []int{0, 0, 0, 1023, 1023, 1023} {
+		w.write(v, 10)
+	}
+	w.write(0, 3)
+	for i := 1; i < 16; i++ {
+		w.write(15, 4)
+	}
+	decodeBC6H(w.block[:], out, false)
+	if p := pixel(out, 0); p != [3]float32{0, 0, 0} {
+		t.Errorf("mode 0x03 pixel 0 = %v, want black", p)
+	}
+	if p := pixel(out, 5); p != [3]float32{65504, 65504, 65504} {
+		t.Errorf("mode 0x03 pixel 5 = %v, want the max half float", p)
+	}
+	// signed, the second endpoint is -1.
+	decodeBC6H(w.block[:], out, true)
+	if p := pixel(out, 5); p[0] != -93*(1.0/(1<<24)) {
+		t.Errorf("signed mode 0x03 pixel 5 = %v, want %v", p, -93*(1.0/(1<<24)))
+	}
+
+	// mode 0x0f, the high bits of w are reversed and x is a delta of -1 from w.
+	w = bitWriter{}
+	w.write(0x0f, 5)
+	w.write(0, 30)
+	w.write(0xf, 4) // rx = -1
+	w.write(1, 1)   // rw[15]
+	w.write(0, 5)
+	w.write(0, 20) // gx, gw[15:10], bx, bw[15:10]
+	w.write(0, 3)
+	for i := 1; i < 16; i++ {
+		w.write(15, 4)
+	}
+	decodeBC6H(w.block[:], out, false)
+	if p := pixel(out, 0); p[0] != 1.5 {
+		t.Errorf("mode 0x0f pixel 0 red = %v, want 1.5", p[0])
+	}
+	if p := pixel(out, 1); p[0] != halfToFloat(0x3dff) {
+		t.Errorf("mode 0x0f pixel 1 red = %v, want %v", p[0], halfToFloat(0x3dff))
+	}
+
+	// mode 0x00 of partition 13, the bottom two rows are of the second subset, whose ry is 15 more than rw.
+	w = bitWriter{}
+	w.write(0x00, 2)
+	w.write(0, 3)
+	w.write(0, 30)
+	w.write(0, 5+1+4+5+1+4+5+1+4)
+	w.write(15, 5) // ry
+	w.write(0, 1+5+1)
+	w.write(13, 5)
+	decodeBC6H(w.block[:], out, false)
+	ry := halfToFloat(uint16(unquantizeBC6H(15, 10, false) * 31 >> 6))
+	for i := 0; i < 16; i++ {
+		want := float32(0)
+		if i >= 8 {
+			want = ry
+		}
+		if p := pixel(out, i); p != [3]float32{want, 0, 0} {
+			t.Errorf("mode 0x00 pixel %d = %v, want red %v", i, p, want)
+		}
+	}
+
+	// the reserved modes decode to black.
+	for i := range out {
+		out[i] = 0xff
+	}
+	w = bitWriter{}
+	w.write(0x13, 5)
+	decodeBC6H(w.block[:], out, false)
+	for i := 0; i < 16; i++ {
+		if p := pixel(out, i); p != [3]float32{} {
+			t.Fatalf("reserved mode pixel %d = %v, want black", i, p)
+		}
+	}
+}
+
+// rgba8 returns the 16 RGBA8 pixels decoded into out.
+func rgba8(out []byte) [16][4]byte {
+	var pixels [16][4]byte
+	for i := range pixels {
+		copy(pixels[i][:], out[i*4:])
+	}
+	return pixels
+}
+
+func bc1Block(c0, c1 uint16, indices uint32) []byte {
+	block := make([]byte, 8)
+	binary.LittleEndian.PutUint16(block, c0)
+	binary.LittleEndian.PutUint16(block[2:], c1)
+	binary.LittleEndian.PutUint32(block[4:], indices)
+	return block
+}
+
+// bc4Block writes the endpoints and the 3-bit indices of a BC4 block.
+func bc4Block(v0, v1 byte, indices [16]int) []byte {
+	var w bitWriter
+	w.write(int(v0), 8)
+	w.write(int(v1), 8)
+	for _, index := range indices {
+		w.write(index, 3)
+	}
+	return w.block[:8]
+}
+
+// the pixel i selects the index i%4 of the colors, the indices 0xe4 are 0, 1, 2, 3.
+const bc1Indices = 0xe4e4e4e4
+
+func TestDecodeBC1(t *testing.T) {
+	out := make([]byte, 64)
+	tests := []struct {
+		name   string
+		block  []byte
+		alpha  bool
+		colors [4][4]byte
+	}{
+		// c0 > c1, the 4-color mode, red to blue.
+		{"4-color", bc1Block(0xf800, 0x001f, bc1Indices), false,
+			[4][4]byte{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}}},
+		{"4-color alpha", bc1Block(0xf800, 0x001f, bc1Indices), true,
+			[4][4]byte{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}}},
+		// c0 <= c1, the 3-color mode, black to the green 32 of 63, the color 3 is black.
+		{"3-color", bc1Block(0x0000, 0x0400, bc1Indices), false,
+			[4][4]byte{{0, 0, 0, 255}, {0, 130, 0, 255}, {0, 65, 0, 255}, {0, 0, 0, 255}}},
+		{"3-color alpha", bc1Block(0x0000, 0x0400, bc1Indices), true,
+			[4][4]byte{{0, 0, 0, 255}, {0, 130, 0, 255}, {0, 65, 0, 255}, {0, 0, 0, 0}}},
+		{"3-color equal", bc1Block(0x001f, 0x001f, bc1Indices), true,
+			[4][4]byte{{0, 0, 255, 255}, {0, 0, 255, 255}, {0, 0, 255, 255}, {0, 0, 0, 0}}},
+	}
+	for _, test := range tests {
+		decodeBC1(test.block, out, test.alpha)
+		for i, p := range rgba8(out) {
+			if want := test.colors[i%4]; p != want {
+				t.Errorf("%s: pixel %d = %v, want %v", test.name, i, p, want)
+			}
+		}
+	}
+}
+
+func TestDecodeBC2(t *testing.T) {
+	// the alpha of the pixel i is i, the colors are in the 4-color mode although c0 <= c1.
+	block := make([]byte, 16)
+	for i := 0; i < 8; i++ {
+		block[i] = byte(2*i) | byte(2*i+1)<<4
+	}
+	copy(block[8:], bc1Block(0x001f, 0xf800, bc1Indices))
+	colors := [4][3]byte{{0, 0, 255}, {255, 0, 0}, {85, 0, 170}, {170, 0, 85}}
+
+	out := make([]byte, 64)
+	decodeBC2(block, out)
+	for i, p := range rgba8(out) {
+		c := colors[i%4]
+		if want := [4]byte{c[0], c[1], c[2], byte(i * 17)}; p != want {
+			t.Errorf("pixel %d = %v, want %v", i, p, want)
+		}
+	}
+}
+
+func TestDecodeBC3(t *testing.T) {
+	// the alpha of the pixel i is the value i%8 of the 8-value mode, the colors are in the 4-color mode.
+	var indices [16]int
+	for i := range indices {
+		indices[i] = i % 8
+	}
+	block := append(bc4Block(70, 0, indices), bc1Block(0x0000, 0x07e0, bc1Indices)...)
+	alphas := [8]byte{70, 0, 60, 50, 40, 30, 20, 10}
+	greens := [4]byte{0, 255, 85, 170}
+
+	out := make([]byte, 64)
+	decodeBC3(block, out)
+	for i, p := range rgba8(out) {
+		if want := [4]byte{0, greens[i%4], 0, alphas[i%8]}; p != want {
+			t.Errorf("pixel %d = %v, want %v", i, p, want)
+		}
+	}
+}
+
+func TestDecodeBC4(t *testing.T) {
+	var indices [16]int
+	for i := range indices {
+		indices[i] = i % 8
+	}
+	tests := []struct {
+		name   string
+		block  []byte
+		signed bool
+		values [8]int
+	}{
+		{"unorm 8-value", bc4Block(70, 0, indices), false, [8]int{70, 0, 60, 50, 40, 30, 20, 10}},
+		{"unorm 6-value", bc4Block(0, 100, indices), false, [8]int{0, 100, 20, 40, 60, 80, 0, 255}},
+		{"snorm 8-value", bc4Block(70, 0xba, indices), true, [8]int{70, -70, 50, 30, 10, -10, -30, -50}},
+		{"snorm 6-value", bc4Block(0xba, 70, indices), true, [8]int{-70, 70, -42, -14, 14, 42, -127, 127}},
+		// -128 is read as -127.
+		{"snorm -128", bc4Block(0x80, 0x80, indices), true, [8]int{-127, -127, -127, -127, -127, -127, -127, 127}},
+	}
+	for _, test := range tests {
+		out := make([]byte, 32)
+		if test.signed {
+			decodeBC4Snorm(test.block, out, 2)
+		} else {
+			decodeBC4Unorm(test.block, out, 2)
+		}
+		for i := 0; i < 16; i++ {
+			got := int(out[i*2])
+			if test.signed {
+				got = int(int8(out[i*2]))
+			}
+			if want := test.values[i%8]; got != want || out[i*2+1] != 0 {
+				t.Errorf("%s: pixel %d = %d, want %d", test.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeBC5(t *testing.T) {
+	var red, green [16]int
+	for i := range red {
+		red[i] = i % 8
+		green[i] = 7 - i%8
+	}
+
+	out := make([]byte, 32)
+	decodeBC5Unorm(append(bc4Block(70, 0, red), bc4Block(0, 100, green)...), out)
+	reds := [8]byte{70, 0, 60, 50, 40, 30, 20, 10}
+	greens := [8]byte{255, 0, 80, 60, 40, 20, 100, 0}
+	for i := 0; i < 16; i++ {
+		if r, g := out[i*2], out[i*2+1]; r != reds[i%8] || g != greens[i%8] {
+			t.Errorf("unorm pixel %d = (%d, %d), want (%d, %d)", i, r, g, reds[i%8], greens[i%8])
+		}
+	}
+
+	decodeBC5Snorm(append(bc4Block(70, 0xba, red), bc4Block(0xba, 70, green)...), out)
+	sreds := [8]int8{70, -70, 50, 30, 10, -10, -30, -50}
+	sgreens := [8]int8{127, -127, 42, 14, -14, -42, 70, -70}
+	for i := 0; i < 16; i++ {
+		if r, g := int8(out[i*2]), int8(out[i*2+1]); r != sreds[i%8] || g != sgreens[i%8] {
+			t.Errorf("snorm pixel %d = (%d, %d), want (%d, %d)", i, r, g, sreds[i%8], sgreens[i%8])
+		}
+	}
+}
+
+func TestDecodeBC7(t *testing.T) {
+	out := make([]byte, 64)
+
+	// mode 6, 7-bit endpoints with a p-bit each, 0 and 127 with the p-bits 0 and 1 are black and white.
+	var w bitWriter
+	w.write(1<<6, 7)
+	for c := 0; c < 4; c++ {
+		w.write(0, 7)
+		w.write(127, 7)
+	}
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(0, 3)
+	for i := 1; i < 16; i++ {
+		w.write(i, 4)
+	}
+	decodeBC7(w.block[:], out)
+	values := [16]byte{0, 16, 36, 52, 68, 84, 104, 120, 135, 151, 171, 187, 203, 219, 239, 255}
+	for i, p := range rgba8(out) {
+		v := values[i]
+		if want := [4]byte{v, v, v, v}; p != want {
+			t.Errorf("mode 6 pixel %d = %v, want %v", i, p, want)
+		}
+	}
+
+	// mode 5 of the rotation 1, the red and the alpha are swapped after the interpolation.
+	w = bitWriter{}
+	w.write(1<<5, 6)
+	w.write(1, 2)
+	for _, v := range []int{0, 127, 0, 0, 64, 64} {
+		w.write(v, 7)
+	}
+	w.write(128, 8)
+	w.write(0, 8)
+	w.write(0, 1)
+	for i := 1; i < 16; i++ {
+		w.write(i%4, 2)
+	}
+	w.write(0, 31)
+	decodeBC7(w.block[:], out)
+	reds := [4]byte{0, 84, 171, 255}
+	for i, p := range rgba8(out) {
+		if want := [4]byte{128, 0, 129, reds[i%4]}; p != want {
+			t.Errorf("mode 5 pixel %d = %v, want %v", i, p, want)
+		}
+	}
+
+	// mode 1 of partition 13, the bottom two rows are of the second subset and its anchor is the pixel 15.
+	w = bitWriter{}
+	w.write(1<<1, 2)
+	w.write(13, 6)
+	for c := 0; c < 2; c++ {
+		for _, v := range []int{0, 63, 63, 63} {
+			w.write(v, 6)
+		}
+	}
+	w.write(0, 4*6)
+	w.write(0, 1) // the p-bit of the first subset
+	w.write(1, 1)
+	for i := 0; i < 16; i++ {
+		switch {
+		case i == 0 || i == 15:
+			w.write(0, 2)
+		case i < 8:
+			w.write(i, 3)
+		default:
+			w.write(0, 3)
+		}
+	}
+	decodeBC7(w.block[:], out)
+	values8 := [8]byte{0, 36, 71, 107, 146, 182, 217, 253}
+	for i, p := range rgba8(out) {
+		want := [4]byte{255, 255, 2, 255} // the blue 0 has the p-bit 1 of the subset
+		if i < 8 {
+			want = [4]byte{values8[i], values8[i], 0, 255}
+		}
+		if p != want {
+			t.Errorf("mode 1 pixel %d = %v, want %v", i, p, want)
+		}
+	}
+
+	// the reserved mode decodes to transparent black.
+	for i := range out {
+		out[i] = 0xff
+	}
+	decodeBC7(make([]byte, 16), out)
+	for i, p := range rgba8(out) {
+		if p != [4]byte{} {
+			t.Fatalf("reserved mode pixel %d = %v, want transparent black", i, p)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// ColorSpace is the color space the pixel data of a texture is encoded in.
+type ColorSpace int
+
+const (
+	// ColorSpaceLinear marks the texture as data, e.g. a normal map or a mask,
+	// the pixels are uploaded as is and read by the shaders unchanged.
+	ColorSpaceLinear ColorSpace = iota
+	// ColorSpaceSRGB marks the texture as sRGB encoded colors,
+	// the GL converts them to linear when the shaders read them.
+	ColorSpaceSRGB
+	// ColorSpaceAuto detects the color space from the sRGB, iCCP and gAMA chunks of the PNG images.
+	// The images without such metadata are sRGB if they are 8-bit color images, linear otherwise.
+	ColorSpaceAuto
+)
+
+func (cs ColorSpace) String() string {
+	switch cs {
+	case ColorSpaceLinear:
+		return "linear"
+	case ColorSpaceSRGB:
+		return "sRGB"
+	case ColorSpaceAuto:
+		return "auto"
+	}
+	return "unknown"
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// PNGColorSpace returns the color space declared by the metadata of the PNG data,
+// it reports false if the data is not a PNG or it has no color space metadata.
+// The chunks are taken in the order of precedence of the PNG specification: sRGB, iCCP, then gAMA.
+func PNGColorSpace(data []byte) (ColorSpace, bool) {
+	if !bytes.HasPrefix(data, pngHeader) {
+		return 0, false
+	}
+
+	var iccp, gama []byte
+	for i := len(pngHeader); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		if length < 0 || i+12+length > len(data) || typ == "IDAT" {
+			break
+		}
+		chunk := data[i+8 : i+8+length]
+		switch typ {
+		case "sRGB":
+			return ColorSpaceSRGB, true
+		case "iCCP":
+			iccp = chunk
+		case "gAMA":
+			gama = chunk
+		}
+		i += 12 + length
+	}
+
+	if iccp != nil {
+		if cs, ok := iccColorSpace(iccp); ok {
+			return cs, true
+		}
+	}
+	if len(gama) == 4 {
+		// the gamma is stored times 100000, 45455 for the sRGB-like 1/2.2 and 100000 for linear.
+		if gamma := float64(binary.BigEndian.Uint32(gama)) / 100000; gamma > 0.75 {
+			return ColorSpaceLinear, true
+		}
+		return ColorSpaceSRGB, true
+	}
+	return 0, false
+}
+
+// iccColorSpace guesses the color space of the iCCP chunk by the names of the profile,
+// the tone curves of the profile are not evaluated.
+func iccColorSpace(chunk []byte) (ColorSpace, bool) {
+	i := bytes.IndexByte(chunk, 0)
+	if i < 0 || i+2 > len(chunk) {
+		return 0, false
+	}
+	if cs, ok := profileColorSpace(chunk[:i]); ok {
+		return cs, true
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(chunk[i+2:]))
+	if err != nil {
+		return 0, false
+	}
+	defer r.Close()
+	profile, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, false
+	}
+	return profileColorSpace(profile)
+}
+
+// profileColorSpace looks for the well known profile names in the text.
+func profileColorSpace(text []byte) (ColorSpace, bool) {
+	switch {
+	case bytes.Contains(text, []byte("sRGB")):
+		return ColorSpaceSRGB, true
+	case bytes.Contains(bytes.ToLower(text), []byte("linear")):
+		return ColorSpaceLinear, true
+	}
+	return 0, false
+}
+
+// resolve returns the color space of the pixels loaded from the data.
+func (cs ColorSpace) resolve(data []byte, p *pixels) ColorSpace {
+	if cs != ColorSpaceAuto {
+		return cs
+	}
+	if detected, ok := PNGColorSpace(data); ok {
+		return detected
+	}
+	if n, c := p.components(); n >= 3 && c == 1 {
+		return ColorSpaceSRGB
+	}
+	return ColorSpaceLinear
+}
+
+// isSRGB reports whether the GL decodes the format from sRGB.
+func (f PixelFormat) isSRGB() bool {
+	return f.InternalFormat == gl.SRGB8 || f.InternalFormat == gl.SRGB8_ALPHA8
+}
+
+// srgb returns the pixels in a format the GL decodes from sRGB:
+// the 8-bit RGB and RGBA pixels use the sRGB formats, the 8-bit gray pixels are expanded to them,
+// and the 16-bit pixels, which have no sRGB format, are converted to linear.
+func (p *pixels) srgb() *pixels {
+	n, c := p.components()
+	if c == 4 {
+		// the float pixels are linear HDR values.
+		return p
+	}
+	if c == 2 {
+		q := *p
+		q.Pix = make([]byte, len(p.Pix))
+		for i := 0; i < len(p.Pix); i += 2 {
+			if n%2 == 0 && (i/2)%n == n-1 {
+				// the alpha is linear.
+				copy(q.Pix[i:i+2], p.Pix[i:i+2])
+				continue
+			}
+			v := float64(binary.BigEndian.Uint16(p.Pix[i:])) / 0xffff
+			binary.BigEndian.PutUint16(q.Pix[i:], uint16(SRGBToLinear(v)*0xffff+0.5))
+		}
+		return &q
+	}
+
+	switch n {
+	case 1:
+		p, n = p.keep(0, 0, 0), 3
+	case 2:
+		p, n = p.keep(0, 0, 0, 1), 4
+	}
+	q := *p
+	q.format.InternalFormat = gl.SRGB8_ALPHA8
+	if n == 3 {
+		q.format.InternalFormat = gl.SRGB8
+	}
+	return &q
+}
+
+// SRGBToLinear converts the sRGB encoded value in [0, 1] to linear.
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB converts the linear value in [0, 1] to sRGB encoded.
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// srgbToLinear8 is the table of the 8-bit sRGB values converted to 16-bit linear values.
+var srgbToLinear8 = func() (table [256]uint16) {
+	for i := range table {
+		table[i] = uint16(SRGBToLinear(float64(i)/255)*0xffff + 0.5)
+	}
+	return
+}()
+
+// ToLinear converts the sRGB image to a linear image for sampling on the CPU, e.g. to blend or filter colors.
+// The result has 16-bit components, so the dark colors keep their precision, and the alpha is unchanged.
+func ToLinear(img image.Image) *image.NRGBA64 {
+	b := img.Bounds()
+	linear := image.NewNRGBA64(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			linear.SetNRGBA64(x, y, color.NRGBA64{
+				R: srgbToLinear8[c.R],
+				G: srgbToLinear8[c.G],
+				B: srgbToLinear8[c.B],
+				A: uint16(c.A) * 0x101,
+			})
+		}
+	}
+	return linear
+}
+
+// ToSRGB converts the linear image to an 8-bit sRGB image, it is the inverse of ToLinear.
+func ToSRGB(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	srgb := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	encode := func(v uint16) uint8 {
+		return uint8(LinearToSRGB(float64(v)/0xffff)*255 + 0.5)
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := color.NRGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA64)
+			srgb.SetNRGBA(x, y, color.NRGBA{
+				R: encode(c.R),
+				G: encode(c.G),
+				B: encode(c.B),
+				A: uint8(c.A >> 8),
+			})
+		}
+	}
+	return srgb
+}
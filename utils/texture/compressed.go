@@ -0,0 +1,535 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ginuerzh/learnopengl/utils/glext"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// the sRGB S3TC formats of GL_EXT_texture_sRGB, which are not in the core profile.
+const (
+	compressedSRGBS3TCDXT1      = 0x8C4C
+	compressedSRGBAlphaS3TCDXT1 = 0x8C4D
+	compressedSRGBAlphaS3TCDXT3 = 0x8C4E
+	compressedSRGBAlphaS3TCDXT5 = 0x8C4F
+)
+
+// CompressedFormat is a GPU block compressed format of 4x4 pixel blocks.
+type CompressedFormat struct {
+	Name           string
+	InternalFormat uint32
+	BlockSize      int  // the bytes of a block
+	SRGB           bool // the colors are sRGB encoded
+	// Extensions are the GL extensions one of which is required for the format, empty if it is core in GL 3.3.
+	Extensions []string
+	// Requires are the GL extensions all of which are required besides one of Extensions,
+	// e.g. the sRGB S3TC formats extend the S3TC formats.
+	Requires []string
+	// Version is the GL version the format became core, e.g. 42 for 4.2, 0 if it is not core.
+	Version int
+
+	fallback PixelFormat             // the format the blocks are decoded to on the CPU
+	decode   func(block, out []byte) // decodes a block to 16 pixels of the fallback format, nil if not supported
+}
+
+// the formats without sRGB variants of the fallback pixels, the GL reads the missing components as 0 and 1.
+var (
+	formatRed8        = PixelFormat{InternalFormat: gl.R8, Format: gl.RED, Type: gl.UNSIGNED_BYTE, Size: 1}
+	formatRedGreen8   = PixelFormat{InternalFormat: gl.RG8, Format: gl.RG, Type: gl.UNSIGNED_BYTE, Size: 2}
+	formatRed8S       = PixelFormat{InternalFormat: gl.R8_SNORM, Format: gl.RED, Type: gl.BYTE, Size: 1}
+	formatRedGreen8S  = PixelFormat{InternalFormat: gl.RG8_SNORM, Format: gl.RG, Type: gl.BYTE, Size: 2}
+	formatRed16       = PixelFormat{InternalFormat: gl.R16, Format: gl.RED, Type: gl.UNSIGNED_SHORT, Size: 2}
+	formatRedGreen16  = PixelFormat{InternalFormat: gl.RG16, Format: gl.RG, Type: gl.UNSIGNED_SHORT, Size: 4}
+	formatRed16S      = PixelFormat{InternalFormat: gl.R16_SNORM, Format: gl.RED, Type: gl.SHORT, Size: 2}
+	formatRedGreen16S = PixelFormat{InternalFormat: gl.RG16_SNORM, Format: gl.RG, Type: gl.SHORT, Size: 4}
+)
+
+var (
+	s3tcExtensions     = []string{"GL_EXT_texture_compression_s3tc"}
+	s3tcSRGBExtensions = []string{"GL_EXT_texture_sRGB", "GL_EXT_texture_compression_s3tc_srgb"}
+	bptcExtensions     = []string{"GL_ARB_texture_compression_bptc"}
+	etc2Extensions     = []string{"GL_ARB_ES3_compatibility"}
+)
+
+// The compressed formats supported in the KTX, KTX2 and DDS files.
+var (
+	FormatBC1RGB = &CompressedFormat{Name: "BC1 RGB", InternalFormat: gl.COMPRESSED_RGB_S3TC_DXT1_EXT, BlockSize: 8,
+		Extensions: s3tcExtensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeBC1(block, out, false) }}
+	FormatBC1RGBA = &CompressedFormat{Name: "BC1 RGBA", InternalFormat: gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, BlockSize: 8,
+		Extensions: s3tcExtensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeBC1(block, out, true) }}
+	FormatBC2 = &CompressedFormat{Name: "BC2", InternalFormat: gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, BlockSize: 16,
+		Extensions: s3tcExtensions, fallback: FormatRGBA8, decode: decodeBC2}
+	FormatBC3 = &CompressedFormat{Name: "BC3", InternalFormat: gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, BlockSize: 16,
+		Extensions: s3tcExtensions, fallback: FormatRGBA8, decode: decodeBC3}
+	FormatBC1RGBSRGB = &CompressedFormat{Name: "BC1 RGB sRGB", InternalFormat: compressedSRGBS3TCDXT1, BlockSize: 8, SRGB: true,
+		Extensions: s3tcSRGBExtensions, Requires: s3tcExtensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeBC1(block, out, false) }}
+	FormatBC1RGBASRGB = &CompressedFormat{Name: "BC1 RGBA sRGB", InternalFormat: compressedSRGBAlphaS3TCDXT1, BlockSize: 8, SRGB: true,
+		Extensions: s3tcSRGBExtensions, Requires: s3tcExtensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeBC1(block, out, true) }}
+	FormatBC2SRGB = &CompressedFormat{Name: "BC2 sRGB", InternalFormat: compressedSRGBAlphaS3TCDXT3, BlockSize: 16, SRGB: true,
+		Extensions: s3tcSRGBExtensions, Requires: s3tcExtensions, fallback: FormatRGBA8, decode: decodeBC2}
+	FormatBC3SRGB = &CompressedFormat{Name: "BC3 sRGB", InternalFormat: compressedSRGBAlphaS3TCDXT5, BlockSize: 16, SRGB: true,
+		Extensions: s3tcSRGBExtensions, Requires: s3tcExtensions, fallback: FormatRGBA8, decode: decodeBC3}
+	FormatBC4 = &CompressedFormat{Name: "BC4", InternalFormat: gl.COMPRESSED_RED_RGTC1, BlockSize: 8, Version: 30,
+		fallback: formatRed8, decode: func(block, out []byte) { decodeBC4Unorm(block, out, 1) }}
+	FormatBC4Signed = &CompressedFormat{Name: "BC4 signed", InternalFormat: gl.COMPRESSED_SIGNED_RED_RGTC1, BlockSize: 8, Version: 30,
+		fallback: formatRed8S, decode: func(block, out []byte) { decodeBC4Snorm(block, out, 1) }}
+	FormatBC5 = &CompressedFormat{Name: "BC5", InternalFormat: gl.COMPRESSED_RG_RGTC2, BlockSize: 16, Version: 30,
+		fallback: formatRedGreen8, decode: decodeBC5Unorm}
+	FormatBC5Signed = &CompressedFormat{Name: "BC5 signed", InternalFormat: gl.COMPRESSED_SIGNED_RG_RGTC2, BlockSize: 16, Version: 30,
+		fallback: formatRedGreen8S, decode: decodeBC5Snorm}
+	FormatBC6H = &CompressedFormat{Name: "BC6H", InternalFormat: gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB, BlockSize: 16, Version: 42,
+		Extensions: bptcExtensions, fallback: FormatRGB16F,
+		decode: func(block, out []byte) { decodeBC6H(block, out, false) }}
+	FormatBC6HSigned = &CompressedFormat{Name: "BC6H signed", InternalFormat: gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT_ARB, BlockSize: 16, Version: 42,
+		Extensions: bptcExtensions, fallback: FormatRGB16F,
+		decode: func(block, out []byte) { decodeBC6H(block, out, true) }}
+	FormatBC7 = &CompressedFormat{Name: "BC7", InternalFormat: gl.COMPRESSED_RGBA_BPTC_UNORM_ARB, BlockSize: 16, Version: 42,
+		Extensions: bptcExtensions, fallback: FormatRGBA8, decode: decodeBC7}
+	FormatBC7SRGB = &CompressedFormat{Name: "BC7 sRGB", InternalFormat: gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB, BlockSize: 16, Version: 42, SRGB: true,
+		Extensions: bptcExtensions, fallback: FormatRGBA8, decode: decodeBC7}
+	FormatETC2RGB = &CompressedFormat{Name: "ETC2 RGB", InternalFormat: gl.COMPRESSED_RGB8_ETC2, BlockSize: 8, Version: 43,
+		Extensions: etc2Extensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeETC2(block, out, false) }}
+	FormatETC2RGBSRGB = &CompressedFormat{Name: "ETC2 RGB sRGB", InternalFormat: gl.COMPRESSED_SRGB8_ETC2, BlockSize: 8, Version: 43, SRGB: true,
+		Extensions: etc2Extensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeETC2(block, out, false) }}
+	FormatETC2RGBA1 = &CompressedFormat{Name: "ETC2 RGB A1", InternalFormat: gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, BlockSize: 8, Version: 43,
+		Extensions: etc2Extensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeETC2(block, out, true) }}
+	FormatETC2RGBA1SRGB = &CompressedFormat{Name: "ETC2 RGB A1 sRGB", InternalFormat: gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2, BlockSize: 8, Version: 43, SRGB: true,
+		Extensions: etc2Extensions, fallback: FormatRGBA8,
+		decode: func(block, out []byte) { decodeETC2(block, out, true) }}
+	FormatETC2RGBA = &CompressedFormat{Name: "ETC2 RGBA", InternalFormat: gl.COMPRESSED_RGBA8_ETC2_EAC, BlockSize: 16, Version: 43,
+		Extensions: etc2Extensions, fallback: FormatRGBA8, decode: decodeETC2EAC}
+	FormatETC2RGBASRGB = &CompressedFormat{Name: "ETC2 RGBA sRGB", InternalFormat: gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC, BlockSize: 16, Version: 43, SRGB: true,
+		Extensions: etc2Extensions, fallback: FormatRGBA8, decode: decodeETC2EAC}
+	FormatEACR11 = &CompressedFormat{Name: "EAC R11", InternalFormat: gl.COMPRESSED_R11_EAC, BlockSize: 8, Version: 43,
+		Extensions: etc2Extensions, fallback: formatRed16,
+		decode: func(block, out []byte) { decodeEAC11(block, out, 2, false) }}
+	FormatEACR11Signed = &CompressedFormat{Name: "EAC R11 signed", InternalFormat: gl.COMPRESSED_SIGNED_R11_EAC, BlockSize: 8, Version: 43,
+		Extensions: etc2Extensions, fallback: formatRed16S,
+		decode: func(block, out []byte) { decodeEAC11(block, out, 2, true) }}
+	FormatEACRG11 = &CompressedFormat{Name: "EAC RG11", InternalFormat: gl.COMPRESSED_RG11_EAC, BlockSize: 16, Version: 43,
+		Extensions: etc2Extensions, fallback: formatRedGreen16,
+		decode: func(block, out []byte) {
+			decodeEAC11(block, out, 4, false)
+			decodeEAC11(block[8:], out[2:], 4, false)
+		}}
+	FormatEACRG11Signed = &CompressedFormat{Name: "EAC RG11 signed", InternalFormat: gl.COMPRESSED_SIGNED_RG11_EAC, BlockSize: 16, Version: 43,
+		Extensions: etc2Extensions, fallback: formatRedGreen16S,
+		decode: func(block, out []byte) {
+			decodeEAC11(block, out, 4, true)
+			decodeEAC11(block[8:], out[2:], 4, true)
+		}}
+)
+
+var compressedFormats = []*CompressedFormat{
+	FormatBC1RGB, FormatBC1RGBA, FormatBC2, FormatBC3,
+	FormatBC1RGBSRGB, FormatBC1RGBASRGB, FormatBC2SRGB, FormatBC3SRGB,
+	FormatBC4, FormatBC4Signed, FormatBC5, FormatBC5Signed,
+	FormatBC6H, FormatBC6HSigned, FormatBC7, FormatBC7SRGB,
+	FormatETC2RGB, FormatETC2RGBSRGB, FormatETC2RGBA1, FormatETC2RGBA1SRGB, FormatETC2RGBA, FormatETC2RGBASRGB,
+	FormatEACR11, FormatEACR11Signed, FormatEACRG11, FormatEACRG11Signed,
+}
+
+func (f *CompressedFormat) String() string {
+	return f.Name
+}
+
+// Supported reports whether the driver supports the format, by the GL version and the extensions.
+// It requires a current GL context.
+func (f *CompressedFormat) Supported() bool {
+	if f.Version > 0 && glext.Version() >= f.Version {
+		return true
+	}
+	for _, ext := range f.Requires {
+		if !glext.HasExtension(ext) {
+			return false
+		}
+	}
+	for _, ext := range f.Extensions {
+		if glext.HasExtension(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanDecode reports whether the format can be decoded on the CPU.
+func (f *CompressedFormat) CanDecode() bool {
+	return f.decode != nil
+}
+
+// maxCompressedSize is the largest width and height of the compressed images,
+// so the sizes of the levels read from a file can not overflow.
+const maxCompressedSize = 1 << 16
+
+func validCompressedSize(width, height int) bool {
+	return width > 0 && height > 0 && width <= maxCompressedSize && height <= maxCompressedSize
+}
+
+// levelSize returns the bytes of a level of the size.
+func (f *CompressedFormat) levelSize(width, height int) int {
+	return (width + 3) / 4 * ((height + 3) / 4) * f.BlockSize
+}
+
+// CompressedImage is a block compressed image with its mip levels, as stored in a KTX, KTX2 or DDS file.
+type CompressedImage struct {
+	Format        *CompressedFormat
+	Width, Height int
+	Levels        [][]byte // the blocks of the mip levels from the base level
+}
+
+// LevelSize returns the size of the mip level.
+func (img *CompressedImage) LevelSize(level int) (int, int) {
+	w, h := img.Width>>uint(level), img.Height>>uint(level)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Decode decodes the mip level on the CPU.
+func (img *CompressedImage) Decode(level int) (image.Image, error) {
+	p, err := img.decode(level)
+	if err != nil {
+		return nil, err
+	}
+	return p.image(), nil
+}
+
+func (img *CompressedImage) decode(level int) (*pixels, error) {
+	f := img.Format
+	if f.decode == nil {
+		return nil, fmt.Errorf("%s can not be decoded", f)
+	}
+	if level < 0 || level >= len(img.Levels) {
+		return nil, fmt.Errorf("invalid level %d", level)
+	}
+	w, h := img.LevelSize(level)
+	data := img.Levels[level]
+
+	size := f.fallback.Size
+	p := &pixels{
+		Pix:    make([]byte, w*h*size),
+		Width:  w,
+		Height: h,
+		format: f.fallback,
+	}
+	if f.SRGB {
+		p.format.InternalFormat = gl.SRGB8_ALPHA8
+	}
+
+	out := make([]byte, 16*size)
+	bw := (w + 3) / 4
+	for by := 0; by < (h+3)/4; by++ {
+		for bx := 0; bx < bw; bx++ {
+			i := (by*bw + bx) * f.BlockSize
+			f.decode(data[i:i+f.BlockSize], out)
+			// copy the pixels inside the level, the blocks on the edges may be partial.
+			for y := 0; y < 4 && by*4+y < h; y++ {
+				n := 4
+				if bx*4+n > w {
+					n = w - bx*4
+				}
+				copy(p.Pix[((by*4+y)*w+bx*4)*size:], out[y*4*size:(y*4+n)*size])
+			}
+		}
+	}
+	return p, nil
+}
+
+var (
+	ktx1Identifier = []byte("\xabKTX 11\xbb\r\n\x1a\n")
+	ktx2Identifier = []byte("\xabKTX 20\xbb\r\n\x1a\n")
+	ddsMagic       = []byte("DDS ")
+)
+
+// IsCompressed reports whether the data is a KTX, KTX2 or DDS file.
+func IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, ktx1Identifier) || bytes.HasPrefix(data, ktx2Identifier) || bytes.HasPrefix(data, ddsMagic)
+}
+
+// DecodeCompressed parses a KTX, KTX2 or DDS file of a 2D texture in a compressed format.
+func DecodeCompressed(data []byte) (*CompressedImage, error) {
+	var img *CompressedImage
+	var err error
+	switch {
+	case bytes.HasPrefix(data, ktx1Identifier):
+		img, err = decodeKTX(data)
+	case bytes.HasPrefix(data, ktx2Identifier):
+		img, err = decodeKTX2(data)
+	case bytes.HasPrefix(data, ddsMagic):
+		img, err = decodeDDS(data)
+	default:
+		return nil, errors.New("not a KTX, KTX2 or DDS file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !validCompressedSize(img.Width, img.Height) {
+		return nil, fmt.Errorf("invalid size %dx%d", img.Width, img.Height)
+	}
+	for level, data := range img.Levels {
+		w, h := img.LevelSize(level)
+		if size := img.Format.levelSize(w, h); len(data) < size {
+			return nil, fmt.Errorf("level %d has %d bytes, expect %d", level, len(data), size)
+		}
+	}
+	return img, nil
+}
+
+func compressedFormatOf(internalFormat uint32) *CompressedFormat {
+	for _, f := range compressedFormats {
+		if f.InternalFormat == internalFormat {
+			return f
+		}
+	}
+	return nil
+}
+
+// decodeKTX parses a KTX 1 file.
+func decodeKTX(data []byte) (*CompressedImage, error) {
+	if len(data) < 64 {
+		return nil, errors.New("ktx: truncated header")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(data[12:]) != 0x04030201 {
+		order = binary.BigEndian
+	}
+	field := func(i int) uint32 { return order.Uint32(data[16+i*4:]) }
+	glType, internalFormat := field(0), field(3)
+	width, height, depth := int(field(5)), int(field(6)), int(field(7))
+	arrays, faces, levels := field(8), field(9), int(field(10))
+	kvBytes := int(field(11))
+
+	if glType != 0 {
+		return nil, errors.New("ktx: not a compressed texture")
+	}
+	if depth > 1 || arrays > 0 || faces > 1 {
+		return nil, errors.New("ktx: only 2D textures are supported")
+	}
+	f := compressedFormatOf(internalFormat)
+	if f == nil {
+		return nil, fmt.Errorf("ktx: unsupported format 0x%x", internalFormat)
+	}
+	if levels == 0 {
+		levels = 1
+	}
+
+	if kvBytes < 0 || kvBytes > len(data)-64 {
+		return nil, errors.New("ktx: truncated key/value data")
+	}
+
+	img := &CompressedImage{Format: f, Width: width, Height: height}
+	i := 64 + kvBytes
+	for level := 0; level < levels; level++ {
+		if i > len(data)-4 {
+			return nil, errors.New("ktx: truncated data")
+		}
+		size := int(order.Uint32(data[i:]))
+		i += 4
+		if size < 0 || size > len(data)-i {
+			return nil, errors.New("ktx: truncated data")
+		}
+		img.Levels = append(img.Levels, data[i:i+size])
+		i += (size + 3) &^ 3
+	}
+	return img, nil
+}
+
+// the VkFormat of the compressed formats in KTX2.
+var vkFormats = map[uint32]*CompressedFormat{
+	131: FormatBC1RGB, 132: FormatBC1RGBSRGB, 133: FormatBC1RGBA, 134: FormatBC1RGBASRGB,
+	135: FormatBC2, 136: FormatBC2SRGB, 137: FormatBC3, 138: FormatBC3SRGB,
+	139: FormatBC4, 140: FormatBC4Signed, 141: FormatBC5, 142: FormatBC5Signed,
+	143: FormatBC6H, 144: FormatBC6HSigned, 145: FormatBC7, 146: FormatBC7SRGB,
+	147: FormatETC2RGB, 148: FormatETC2RGBSRGB, 149: FormatETC2RGBA1, 150: FormatETC2RGBA1SRGB,
+	151: FormatETC2RGBA, 152: FormatETC2RGBASRGB,
+	153: FormatEACR11, 154: FormatEACR11Signed, 155: FormatEACRG11, 156: FormatEACRG11Signed,
+}
+
+// decodeKTX2 parses a KTX 2 file without supercompression.
+func decodeKTX2(data []byte) (*CompressedImage, error) {
+	if len(data) < 80 {
+		return nil, errors.New("ktx2: truncated header")
+	}
+	field := func(i int) uint32 { return binary.LittleEndian.Uint32(data[12+i*4:]) }
+	vkFormat := field(0)
+	width, height, depth := int(field(2)), int(field(3)), field(4)
+	layers, faces, levels := field(5), field(6), int(field(7))
+	supercompression := field(8)
+
+	if supercompression != 0 {
+		return nil, fmt.Errorf("ktx2: unsupported supercompression scheme %d", supercompression)
+	}
+	if depth > 1 || layers > 1 || faces > 1 {
+		return nil, errors.New("ktx2: only 2D textures are supported")
+	}
+	f := vkFormats[vkFormat]
+	if f == nil {
+		return nil, fmt.Errorf("ktx2: unsupported format %d", vkFormat)
+	}
+	if levels == 0 {
+		levels = 1
+	}
+
+	img := &CompressedImage{Format: f, Width: width, Height: height}
+	for level := 0; level < levels; level++ {
+		i := 80 + level*24
+		if i+24 > len(data) {
+			return nil, errors.New("ktx2: truncated level index")
+		}
+		offset := binary.LittleEndian.Uint64(data[i:])
+		size := binary.LittleEndian.Uint64(data[i+8:])
+		if offset > uint64(len(data)) || size > uint64(len(data))-offset {
+			return nil, errors.New("ktx2: truncated data")
+		}
+		img.Levels = append(img.Levels, data[offset:offset+size])
+	}
+	return img, nil
+}
+
+// the DXGI formats of the compressed formats in the DX10 header of DDS, the typeless formats are taken as UNORM.
+var dxgiFormats = map[uint32]*CompressedFormat{
+	70: FormatBC1RGBA, 71: FormatBC1RGBA, 72: FormatBC1RGBASRGB,
+	73: FormatBC2, 74: FormatBC2, 75: FormatBC2SRGB,
+	76: FormatBC3, 77: FormatBC3, 78: FormatBC3SRGB,
+	79: FormatBC4, 80: FormatBC4, 81: FormatBC4Signed,
+	82: FormatBC5, 83: FormatBC5, 84: FormatBC5Signed,
+	94: FormatBC6H, 95: FormatBC6H, 96: FormatBC6HSigned,
+	97: FormatBC7, 98: FormatBC7, 99: FormatBC7SRGB,
+}
+
+// the FourCC of the compressed formats in DDS.
+var fourCCFormats = map[string]*CompressedFormat{
+	"DXT1": FormatBC1RGBA,
+	"DXT2": FormatBC2, "DXT3": FormatBC2,
+	"DXT4": FormatBC3, "DXT5": FormatBC3,
+	"ATI1": FormatBC4, "BC4U": FormatBC4, "BC4S": FormatBC4Signed,
+	"ATI2": FormatBC5, "BC5U": FormatBC5, "BC5S": FormatBC5Signed,
+}
+
+// decodeDDS parses a DDS file.
+func decodeDDS(data []byte) (*CompressedImage, error) {
+	if len(data) < 128 {
+		return nil, errors.New("dds: truncated header")
+	}
+	field := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[4+offset:]) }
+	flags := field(4)
+	height, width := int(field(8)), int(field(12))
+	levels := 1
+	if flags&0x20000 != 0 && field(24) > 0 { // DDSD_MIPMAPCOUNT
+		levels = int(field(24))
+	}
+	if field(108)&0x200 != 0 { // DDSCAPS2_CUBEMAP
+		return nil, errors.New("dds: only 2D textures are supported")
+	}
+	if field(76)&0x4 == 0 { // DDPF_FOURCC
+		return nil, errors.New("dds: not a compressed texture")
+	}
+
+	fourCC := string(data[84:88])
+	i := 128
+	var f *CompressedFormat
+	if fourCC == "DX10" {
+		if len(data) < 148 {
+			return nil, errors.New("dds: truncated DX10 header")
+		}
+		dxgi := binary.LittleEndian.Uint32(data[128:])
+		if dimension := binary.LittleEndian.Uint32(data[132:]); dimension != 3 { // D3D10_RESOURCE_DIMENSION_TEXTURE2D
+			return nil, errors.New("dds: only 2D textures are supported")
+		}
+		if misc, arrays := binary.LittleEndian.Uint32(data[136:]), binary.LittleEndian.Uint32(data[140:]); misc&0x4 != 0 || arrays > 1 {
+			return nil, errors.New("dds: only 2D textures are supported")
+		}
+		if f = dxgiFormats[dxgi]; f == nil {
+			return nil, fmt.Errorf("dds: unsupported DXGI format %d", dxgi)
+		}
+		i = 148
+	} else if f = fourCCFormats[fourCC]; f == nil {
+		return nil, fmt.Errorf("dds: unsupported format %q", strings.TrimRight(fourCC, "\x00"))
+	}
+
+	if !validCompressedSize(width, height) {
+		return nil, fmt.Errorf("dds: invalid size %dx%d", width, height)
+	}
+
+	img := &CompressedImage{Format: f, Width: width, Height: height}
+	for level := 0; level < levels; level++ {
+		w, h := img.LevelSize(level)
+		size := f.levelSize(w, h)
+		if size > len(data)-i {
+			return nil, errors.New("dds: truncated data")
+		}
+		img.Levels = append(img.Levels, data[i:i+size])
+		i += size
+	}
+	return img, nil
+}
+
+// LoadCompressed loads a KTX, KTX2 or DDS file with its mip levels to the texture.
+// The levels are uploaded compressed if the driver supports the format,
+// otherwise they are decoded on the CPU, or an error is returned if the format can not be decoded.
+// The mipmaps are not generated, the texture has the levels in the file only.
+func (texture *Texture2D) LoadCompressed(textureFile string) (*CompressedImage, error) {
+	data, err := ioutil.ReadFile(textureFile)
+	if err != nil {
+		return nil, err
+	}
+	img, err := DecodeCompressed(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", textureFile, err)
+	}
+
+	if err := texture.uploadCompressed(img); err != nil {
+		return nil, fmt.Errorf("%s: %v", textureFile, err)
+	}
+	return img, nil
+}
+
+// uploadCompressed uploads the levels of the image to the texture.
+func (texture *Texture2D) uploadCompressed(img *CompressedImage) error {
+	f := img.Format
+	native := f.Supported()
+	if !native && !f.CanDecode() {
+		return fmt.Errorf("%s is not supported by the driver and can not be decoded", f)
+	}
+
+	for level, data := range img.Levels {
+		if !native {
+			p, err := img.decode(level)
+			if err != nil {
+				return err
+			}
+			p.texImage2D(gl.TEXTURE_2D, int32(level))
+			texture.format = p.format
+			continue
+		}
+		w, h := img.LevelSize(level)
+		size := f.levelSize(w, h)
+		gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(level), f.InternalFormat, int32(w), int32(h), 0, int32(size), gl.Ptr(data[:size]))
+		texture.format = PixelFormat{InternalFormat: int32(f.InternalFormat)}
+	}
+	// the texture is complete with the levels in the file.
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_BASE_LEVEL, 0)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(len(img.Levels)-1))
+	setSwizzle(gl.TEXTURE_2D, PixelFormat{})
+	texture.srgb = f.SRGB
+	return texture.setLevels(len(img.Levels), mipLevels(img.Width, img.Height))
+}
@@ -0,0 +1,101 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ktx2File returns a KTX2 file of a 4x4 BC1 image with the level index entry.
+func ktx2File(offset, size uint64) []byte {
+	data := make([]byte, 104+8)
+	copy(data, ktx2Identifier)
+	for i, v := range []uint32{131, 1, 4, 4, 0, 0, 1, 1, 0} {
+		binary.LittleEndian.PutUint32(data[12+i*4:], v)
+	}
+	binary.LittleEndian.PutUint64(data[80:], offset)
+	binary.LittleEndian.PutUint64(data[88:], size)
+	return data
+}
+
+// ktxFile returns a KTX file of a 4x4 BC1 image with the key/value bytes and the level size.
+func ktxFile(kvBytes, size uint32) []byte {
+	data := make([]byte, 64+4+8)
+	copy(data, ktx1Identifier)
+	binary.LittleEndian.PutUint32(data[12:], 0x04030201)
+	for i, v := range []uint32{0, 1, 0, FormatBC1RGBA.InternalFormat, 0, 4, 4, 0, 0, 1, 1, kvBytes} {
+		binary.LittleEndian.PutUint32(data[16+i*4:], v)
+	}
+	binary.LittleEndian.PutUint32(data[64:], size)
+	return data
+}
+
+// ddsFile returns a DXT1 DDS file of the size with the bytes of pixel data.
+func ddsFile(width, height uint32, n int) []byte {
+	data := make([]byte, 128+n)
+	copy(data, ddsMagic)
+	binary.LittleEndian.PutUint32(data[4:], 124)
+	binary.LittleEndian.PutUint32(data[12:], height)
+	binary.LittleEndian.PutUint32(data[16:], width)
+	binary.LittleEndian.PutUint32(data[80:], 0x4)
+	copy(data[84:], "DXT1")
+	return data
+}
+
+func TestDecodeCompressed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"ktx2": ktx2File(104, 8),
+		"ktx":  ktxFile(0, 8),
+		"dds":  ddsFile(4, 4, 8),
+	} {
+		img, err := DecodeCompressed(data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if img.Format != FormatBC1RGB && img.Format != FormatBC1RGBA {
+			t.Errorf("%s: format %v", name, img.Format)
+		}
+		if img.Width != 4 || img.Height != 4 || len(img.Levels) != 1 || len(img.Levels[0]) != 8 {
+			t.Errorf("%s: %dx%d, %d levels", name, img.Width, img.Height, len(img.Levels))
+		}
+	}
+}
+
+func TestDecodeCompressedInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		// the offset and the size wrap around in uint64.
+		{"ktx2 offset overflow", ktx2File(math.MaxUint64, 2), "ktx2: truncated data"},
+		{"ktx2 size overflow", ktx2File(104, math.MaxUint64-100), "ktx2: truncated data"},
+		{"ktx2 truncated", ktx2File(104, 9), "ktx2: truncated data"},
+		{"ktx2 level index", ktx2File(104, 8)[:90], "ktx2: truncated"},
+		{"ktx key/value", ktxFile(math.MaxUint32, 8), "ktx: truncated key/value data"},
+		{"ktx level size", ktxFile(0, math.MaxUint32), "ktx: truncated data"},
+		{"ktx short level", ktxFile(0, 4), "level 0 has 4 bytes, expect 8"},
+		{"dds size", ddsFile(math.MaxUint32, math.MaxUint32, 8), "dds: invalid size"},
+		{"dds truncated", ddsFile(8, 8, 16), "dds: truncated data"},
+		{"ktx2 size", func() []byte {
+			data := ktx2File(104, 8)
+			binary.LittleEndian.PutUint32(data[20:], 1<<20)
+			return data
+		}(), "invalid size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCompressed(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	if IsCompressed([]byte("\x89PNG")) || !IsCompressed(bytes.Repeat(ddsMagic, 2)) {
+		t.Error("IsCompressed")
+	}
+}
@@ -0,0 +1,356 @@
+package texture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// CubeFaceNames are the names of the face images of a cube map, in the order of the faces
+// from gl.TEXTURE_CUBE_MAP_POSITIVE_X: +X, -X, +Y, -Y, +Z and -Z.
+var CubeFaceNames = [6]string{"right", "left", "top", "bottom", "front", "back"}
+
+// the cells of the faces in the cross layouts, in the order of CubeFaceNames.
+var (
+	horizontalCross = [6]image.Point{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}
+	verticalCross   = [6]image.Point{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}}
+)
+
+type TextureCube struct {
+	ID uint32
+	// Conversion selects the pixel format the faces are uploaded with, the source format is preserved by default.
+	Conversion Conversion
+	// ColorSpace is the color space of the faces, as of Texture2D.
+	ColorSpace ColorSpace
+	// Float32 uploads the HDR faces as RGB32F rather than RGB16F.
+	Float32 bool
+	sampling
+	format PixelFormat
+	srgb   bool
+	size   int
+}
+
+func NewTextureCube() Texture {
+	var id uint32
+	gl.GenTextures(1, &id)
+	return &TextureCube{ID: id}
+}
+
+func (texture *TextureCube) SetParameter(name uint32, param interface{}) error {
+	return texture.setParameter(gl.TEXTURE_CUBE_MAP, name, param)
+}
+
+// SetSampler validates and applies the sampler state to the texture, which must be bound.
+func (texture *TextureCube) SetSampler(s Sampler) error {
+	return texture.setSampler(gl.TEXTURE_CUBE_MAP, s)
+}
+
+// Sampler returns the sampler state of the texture.
+func (texture *TextureCube) Sampler() Sampler {
+	return texture.state()
+}
+
+// Load loads the cube map from a directory of the face images named by CubeFaceNames, e.g. right.jpg,
+// or from a single image in a layout told by its aspect ratio: a horizontal cross (4:3), a vertical cross (3:4),
+// or an equirectangular panorama (2:1) whose faces are half its height.
+// The faces are flipped after they are taken from the image. It returns the loaded image as RGBA,
+// the faces of a directory are returned in a horizontal cross.
+func (texture *TextureCube) Load(file string, flipH, flipV bool) (*image.RGBA, error) {
+	if fi, err := os.Stat(file); err == nil && fi.IsDir() {
+		files, err := CubeFaceFiles(file)
+		if err != nil {
+			return nil, err
+		}
+		faces, err := texture.loadFaces(files, flipH, flipV)
+		if err != nil {
+			return nil, err
+		}
+		return crossImage(faces), nil
+	}
+
+	data, p, err := texture.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var faces [6]*pixels
+	switch w, h := p.Width, p.Height; {
+	case w*3 == h*4 || w*4 == h*3:
+		faces, err = crossFaces(p)
+	case w == h*2:
+		faces = equirectangularFaces(p, h/2)
+	default:
+		err = fmt.Errorf("unknown cube map layout of size %dx%d", w, h)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if err := texture.upload(data, flipFaces(faces, flipH, flipV)); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return p.rgba(), nil
+}
+
+// LoadFaces loads the six face images, in the order of CubeFaceNames.
+func (texture *TextureCube) LoadFaces(files [6]string, flipH, flipV bool) error {
+	_, err := texture.loadFaces(files, flipH, flipV)
+	return err
+}
+
+// LoadCross loads the cube map from an image of the faces in a horizontal or a vertical cross:
+//
+//	    +Y                 +Y
+//	-X  +Z  +X  -Z     -X  +Z  +X
+//	    -Y                 -Y
+//	                       -Z
+//
+// The -Z face of the vertical cross is stored upside down.
+func (texture *TextureCube) LoadCross(file string, flipH, flipV bool) error {
+	data, p, err := texture.readFile(file)
+	if err != nil {
+		return err
+	}
+	faces, err := crossFaces(p)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	if err := texture.upload(data, flipFaces(faces, flipH, flipV)); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	return nil
+}
+
+// LoadEquirectangular loads the cube map from an equirectangular panorama, e.g. an HDR environment map,
+// the faces of the size are sampled from it bilinearly on the CPU.
+// The center of the panorama faces +X, as sampled by atan(z, x) in the shaders.
+func (texture *TextureCube) LoadEquirectangular(file string, size int) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid face size %d", size)
+	}
+	data, p, err := texture.readFile(file)
+	if err != nil {
+		return err
+	}
+	if err := texture.upload(data, equirectangularFaces(p, size)); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	return nil
+}
+
+// Format returns the pixel format of the loaded faces.
+func (texture *TextureCube) Format() PixelFormat {
+	return texture.format
+}
+
+// IsSRGB reports whether the loaded faces are sRGB encoded.
+func (texture *TextureCube) IsSRGB() bool {
+	return texture.srgb
+}
+
+// Size returns the width and height of the loaded faces.
+func (texture *TextureCube) Size() int {
+	return texture.size
+}
+
+func (texture *TextureCube) Use() {
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture.ID)
+}
+
+// CubeFaceFiles finds the face images in the directory, the files named by CubeFaceNames with any extension.
+func CubeFaceFiles(dir string) ([6]string, error) {
+	var files [6]string
+	for i, name := range CubeFaceNames {
+		matches, err := filepath.Glob(filepath.Join(dir, name+".*"))
+		if err != nil {
+			return files, err
+		}
+		if len(matches) == 0 {
+			return files, fmt.Errorf("no %s face in %s", name, dir)
+		}
+		files[i] = matches[0]
+	}
+	return files, nil
+}
+
+func (texture *TextureCube) readFile(file string) ([]byte, *pixels, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := decodePixels(data, texture.Conversion, texture.Float32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return data, p, nil
+}
+
+func (texture *TextureCube) loadFaces(files [6]string, flipH, flipV bool) ([6]*pixels, error) {
+	var faces [6]*pixels
+	var first []byte
+	for i, file := range files {
+		data, p, err := texture.readFile(file)
+		if err != nil {
+			return faces, err
+		}
+		if i == 0 {
+			first = data
+		}
+		faces[i] = p
+	}
+	if err := texture.upload(first, flipFaces(faces, flipH, flipV)); err != nil {
+		return faces, err
+	}
+	return faces, nil
+}
+
+// upload validates the faces and uploads them to the texture,
+// the color space is resolved from the data of the first face.
+func (texture *TextureCube) upload(data []byte, faces [6]*pixels) error {
+	size, format := faces[0].Width, faces[0].format
+	for i, p := range faces {
+		if p.Width != p.Height {
+			return fmt.Errorf("cube face %s is %dx%d, it must be square", CubeFaceNames[i], p.Width, p.Height)
+		}
+		if p.Width != size {
+			return fmt.Errorf("cube face %s is %dx%d, expect %dx%d", CubeFaceNames[i], p.Width, p.Height, size, size)
+		}
+		if p.format != format {
+			return fmt.Errorf("cube face %s has format 0x%x, expect 0x%x", CubeFaceNames[i], p.format.InternalFormat, format.InternalFormat)
+		}
+	}
+
+	srgb := texture.ColorSpace.resolve(data, faces[0]) == ColorSpaceSRGB
+	for i, p := range faces {
+		if srgb {
+			p = p.srgb()
+		}
+		p.texImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), 0)
+		texture.format = p.format
+	}
+	texture.srgb = texture.format.isSRGB()
+	setSwizzle(gl.TEXTURE_CUBE_MAP, texture.format)
+	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	texture.size = size
+	return texture.setLevels(mipLevels(size), mipLevels(size))
+}
+
+func flipFaces(faces [6]*pixels, flipH, flipV bool) [6]*pixels {
+	for i := range faces {
+		if flipH {
+			faces[i] = faces[i].flipH()
+		}
+		if flipV {
+			faces[i] = faces[i].flipV()
+		}
+	}
+	return faces
+}
+
+// crossFaces takes the faces from the pixels of a cross layout.
+func crossFaces(p *pixels) ([6]*pixels, error) {
+	var faces [6]*pixels
+	cells, size := horizontalCross, p.Width/4
+	if p.Width*4 == p.Height*3 {
+		cells, size = verticalCross, p.Width/3
+	} else if p.Width*3 != p.Height*4 {
+		return faces, fmt.Errorf("size %dx%d is not of a cross layout", p.Width, p.Height)
+	}
+	if size == 0 {
+		return faces, errors.New("empty cross layout")
+	}
+
+	for i, cell := range cells {
+		x0, y0 := cell.X*size, cell.Y*size
+		faces[i] = p.transform(size, size, func(x, y int) (int, int) { return x0 + x, y0 + y })
+	}
+	if cells == verticalCross {
+		faces[5], _ = faces[5].rotate(180)
+	}
+	return faces, nil
+}
+
+// crossImage returns the faces in a horizontal cross.
+func crossImage(faces [6]*pixels) *image.RGBA {
+	size := faces[0].Width
+	img := image.NewRGBA(image.Rect(0, 0, size*4, size*3))
+	for i, cell := range horizontalCross {
+		r := image.Rect(cell.X*size, cell.Y*size, (cell.X+1)*size, (cell.Y+1)*size)
+		draw.Draw(img, r, faces[i].image(), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// cubeDirection returns the direction of the point (s, t) in [-1, 1] of the face,
+// as the GL selects the face and its coordinates from a direction.
+func cubeDirection(face int, s, t float64) (x, y, z float64) {
+	switch face {
+	case 0:
+		return 1, -t, -s
+	case 1:
+		return -1, -t, s
+	case 2:
+		return s, 1, t
+	case 3:
+		return s, -1, -t
+	case 4:
+		return s, -t, 1
+	}
+	return -s, -t, -1
+}
+
+// equirectangularFaces samples the faces of the size from the pixels of an equirectangular panorama.
+func equirectangularFaces(p *pixels, size int) [6]*pixels {
+	n, _ := p.components()
+	values := make([]float64, n)
+	var faces [6]*pixels
+	for face := range faces {
+		q := *p
+		q.Pix = make([]byte, size*size*p.format.Size)
+		q.Width, q.Height = size, size
+
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				s := 2*(float64(x)+0.5)/float64(size) - 1
+				t := 2*(float64(y)+0.5)/float64(size) - 1
+				dx, dy, dz := cubeDirection(face, s, t)
+				u := math.Atan2(dz, dx)/(2*math.Pi) + 0.5
+				v := 0.5 - math.Asin(dy/math.Sqrt(dx*dx+dy*dy+dz*dz))/math.Pi
+				p.sample(u, v, values)
+				for c, value := range values {
+					q.setComponent((y*size+x)*n+c, value)
+				}
+			}
+		}
+		faces[face] = &q
+	}
+	return faces
+}
+
+// sample samples the components of the pixels at (u, v) in [0, 1] bilinearly,
+// the u wraps around as of a panorama, and the v is clamped.
+func (p *pixels) sample(u, v float64, values []float64) {
+	n := len(values)
+	fx := u*float64(p.Width) - 0.5
+	fy := math.Max(0, math.Min(float64(p.Height-1), v*float64(p.Height)-0.5))
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	ax, ay := fx-float64(x0), fy-float64(y0)
+	x1, y1 := x0+1, y0+1
+	if y1 >= p.Height {
+		y1 = p.Height - 1
+	}
+	x0 = (x0%p.Width + p.Width) % p.Width
+	x1 = (x1%p.Width + p.Width) % p.Width
+
+	for c := 0; c < n; c++ {
+		at := func(x, y int) float64 { return p.component((y*p.Width+x)*n + c) }
+		top := at(x0, y0)*(1-ax) + at(x1, y0)*ax
+		bottom := at(x0, y1)*(1-ax) + at(x1, y1)*ax
+		values[c] = top*(1-ay) + bottom*ay
+	}
+}
@@ -0,0 +1,306 @@
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// The decoders of the ETC2 and EAC blocks, each decodes a 4x4 block into 16 pixels of the fallback format
+// of the block, the pixels are row by row. The pixel indices of the blocks are column by column.
+
+var etcModifiers = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+// the distances of the T and H modes.
+var etcDistances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+func clamp255(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// bits returns n bits of v from the bit from, counting from the least significant bit.
+func bits(v uint64, from, n uint) int {
+	return int(v >> from & (1<<n - 1))
+}
+
+func extend4(v int) int { return v<<4 | v }
+func extend5(v int) int { return v<<3 | v>>2 }
+func extend6(v int) int { return v<<2 | v>>4 }
+func extend7(v int) int { return v<<1 | v>>6 }
+
+// decodeETC2 decodes an ETC2 RGB8 block, or an RGB8 punchthrough alpha block if punchthrough is true,
+// into RGBA8 pixels.
+func decodeETC2(block, out []byte, punchthrough bool) {
+	v := binary.BigEndian.Uint64(block)
+	diff := bits(v, 33, 1) == 1
+	opaque := true
+	if punchthrough {
+		// the differential bit is the opaque bit, and the individual mode does not exist.
+		opaque, diff = diff, true
+	}
+
+	var pixels [16][4]int
+	if !diff {
+		base := [2][3]int{
+			{extend4(bits(v, 60, 4)), extend4(bits(v, 52, 4)), extend4(bits(v, 44, 4))},
+			{extend4(bits(v, 56, 4)), extend4(bits(v, 48, 4)), extend4(bits(v, 40, 4))},
+		}
+		etcSubblocks(v, base, opaque, &pixels)
+		etcStore(pixels, out)
+		return
+	}
+
+	r, dr := bits(v, 59, 5), bits(v, 56, 3)
+	g, dg := bits(v, 51, 5), bits(v, 48, 3)
+	b, db := bits(v, 43, 5), bits(v, 40, 3)
+	signed := func(d int) int { return int(int8(d<<5)) >> 5 } // 3-bit two's complement
+	r2, g2, b2 := r+signed(dr), g+signed(dg), b+signed(db)
+
+	switch {
+	case r2 < 0 || r2 > 31:
+		etcT(v, opaque, &pixels)
+	case g2 < 0 || g2 > 31:
+		etcH(v, opaque, &pixels)
+	case b2 < 0 || b2 > 31:
+		etcPlanar(v, &pixels)
+	default:
+		base := [2][3]int{
+			{extend5(r), extend5(g), extend5(b)},
+			{extend5(r2), extend5(g2), extend5(b2)},
+		}
+		etcSubblocks(v, base, opaque, &pixels)
+	}
+	etcStore(pixels, out)
+}
+
+// etcIndex returns the 2-bit index of the pixel (x, y).
+func etcIndex(v uint64, x, y int) int {
+	i := uint(x*4 + y)
+	return bits(v, 16+i, 1)<<1 | bits(v, i, 1)
+}
+
+// etcSubblocks decodes the individual and the differential modes.
+func etcSubblocks(v uint64, base [2][3]int, opaque bool, pixels *[16][4]int) {
+	flip := bits(v, 32, 1) == 1
+	tables := [2]int{bits(v, 37, 3), bits(v, 34, 3)}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			sub := x / 2
+			if flip {
+				sub = y / 2
+			}
+			index := etcIndex(v, x, y)
+			modifier := etcModifiers[tables[sub]][index]
+			p := &pixels[y*4+x]
+			if !opaque {
+				// the index 2 is transparent, and the index 0 is the base color.
+				switch index {
+				case 0:
+					modifier = 0
+				case 2:
+					*p = [4]int{}
+					continue
+				}
+			}
+			for c := 0; c < 3; c++ {
+				p[c] = clamp255(base[sub][c] + modifier)
+			}
+			p[3] = 255
+		}
+	}
+}
+
+// etcPaint fills the pixels with the paint colors selected by their indices.
+func etcPaint(v uint64, paint [4][3]int, opaque bool, pixels *[16][4]int) {
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			index := etcIndex(v, x, y)
+			p := &pixels[y*4+x]
+			if !opaque && index == 2 {
+				*p = [4]int{}
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				p[c] = clamp255(paint[index][c])
+			}
+			p[3] = 255
+		}
+	}
+}
+
+// etcT decodes the T mode.
+func etcT(v uint64, opaque bool, pixels *[16][4]int) {
+	c1 := [3]int{extend4(bits(v, 59, 2)<<2 | bits(v, 56, 2)), extend4(bits(v, 52, 4)), extend4(bits(v, 48, 4))}
+	c2 := [3]int{extend4(bits(v, 44, 4)), extend4(bits(v, 40, 4)), extend4(bits(v, 36, 4))}
+	d := etcDistances[bits(v, 34, 2)<<1|bits(v, 32, 1)]
+
+	var paint [4][3]int
+	for c := 0; c < 3; c++ {
+		paint[0][c] = c1[c]
+		paint[1][c] = c2[c] + d
+		paint[2][c] = c2[c]
+		paint[3][c] = c2[c] - d
+	}
+	etcPaint(v, paint, opaque, pixels)
+}
+
+// etcH decodes the H mode.
+func etcH(v uint64, opaque bool, pixels *[16][4]int) {
+	r1, g1, b1 := bits(v, 59, 4), bits(v, 56, 3)<<1|bits(v, 52, 1), bits(v, 51, 1)<<3|bits(v, 47, 3)
+	r2, g2, b2 := bits(v, 43, 4), bits(v, 39, 4), bits(v, 35, 4)
+	index := bits(v, 34, 1)<<2 | bits(v, 32, 1)<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		index |= 1
+	}
+	d := etcDistances[index]
+
+	c1 := [3]int{extend4(r1), extend4(g1), extend4(b1)}
+	c2 := [3]int{extend4(r2), extend4(g2), extend4(b2)}
+	var paint [4][3]int
+	for c := 0; c < 3; c++ {
+		paint[0][c] = c1[c] + d
+		paint[1][c] = c1[c] - d
+		paint[2][c] = c2[c] + d
+		paint[3][c] = c2[c] - d
+	}
+	etcPaint(v, paint, opaque, pixels)
+}
+
+// etcPlanar decodes the planar mode.
+func etcPlanar(v uint64, pixels *[16][4]int) {
+	o := [3]int{
+		extend6(bits(v, 57, 6)),
+		extend7(bits(v, 56, 1)<<6 | bits(v, 49, 6)),
+		extend6(bits(v, 48, 1)<<5 | bits(v, 43, 2)<<3 | bits(v, 39, 3)),
+	}
+	h := [3]int{
+		extend6(bits(v, 34, 5)<<1 | bits(v, 32, 1)),
+		extend7(bits(v, 25, 7)),
+		extend6(bits(v, 19, 6)),
+	}
+	vv := [3]int{
+		extend6(bits(v, 13, 6)),
+		extend7(bits(v, 6, 7)),
+		extend6(bits(v, 0, 6)),
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := &pixels[y*4+x]
+			for c := 0; c < 3; c++ {
+				p[c] = clamp255((x*(h[c]-o[c]) + y*(vv[c]-o[c]) + 4*o[c] + 2) >> 2)
+			}
+			p[3] = 255
+		}
+	}
+}
+
+func etcStore(pixels [16][4]int, out []byte) {
+	for i, p := range pixels {
+		for c := 0; c < 4; c++ {
+			out[i*4+c] = byte(p[c])
+		}
+	}
+}
+
+// decodeETC2EAC decodes an ETC2 RGBA8 block, an EAC alpha block followed by an ETC2 RGB8 block, into RGBA8 pixels.
+func decodeETC2EAC(block, out []byte) {
+	decodeETC2(block[8:], out, false)
+
+	v := binary.BigEndian.Uint64(block)
+	base, multiplier, table := bits(v, 56, 8), bits(v, 52, 4), bits(v, 48, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			index := bits(v, uint(45-3*(x*4+y)), 3)
+			out[(y*4+x)*4+3] = byte(clamp255(base + eacModifiers[table][index]*multiplier))
+		}
+	}
+}
+
+// decodeEAC11 decodes an EAC R11 block into the 16-bit big endian components of out,
+// the components of two pixels are stride bytes apart. The signed components are two's complement.
+func decodeEAC11(block, out []byte, stride int, signed bool) {
+	v := binary.BigEndian.Uint64(block)
+	base, multiplier, table := bits(v, 56, 8), bits(v, 52, 4), bits(v, 48, 4)
+	if signed {
+		base = int(int8(base))
+		if base == -128 {
+			base = -127
+		}
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			modifier := eacModifiers[table][bits(v, uint(45-3*(x*4+y)), 3)]
+			var value int
+			if signed {
+				value = base * 8
+			} else {
+				value = base*8 + 4
+			}
+			if multiplier == 0 {
+				value += modifier
+			} else {
+				value += modifier * multiplier * 8
+			}
+
+			var c uint16
+			if signed {
+				if value < -1023 {
+					value = -1023
+				} else if value > 1023 {
+					value = 1023
+				}
+				// extend the 11-bit magnitude to 15 bits
+				magnitude := value
+				if magnitude < 0 {
+					magnitude = -magnitude
+				}
+				magnitude = magnitude<<5 | magnitude>>5
+				if value < 0 {
+					magnitude = -magnitude
+				}
+				c = uint16(int16(magnitude))
+			} else {
+				if value < 0 {
+					value = 0
+				} else if value > 2047 {
+					value = 2047
+				}
+				c = uint16(value<<5 | value>>6)
+			}
+			binary.BigEndian.PutUint16(out[(y*4+x)*stride:], c)
+		}
+	}
+}
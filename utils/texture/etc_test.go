@@ -0,0 +1,190 @@
+package texture
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// etcBlock returns the ETC2 block of the bits v and the 2-bit indices of the pixels,
+// the most and the least significant bits of the indices are in the bits 31-16 and 15-0 column by column.
+func etcBlock(v uint64, index func(x, y int) int) []byte {
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			i := uint(x*4 + y)
+			v |= uint64(index(x, y)>>1)<<(16+i) | uint64(index(x, y)&1)<<i
+		}
+	}
+	block := make([]byte, 8)
+	binary.BigEndian.PutUint64(block, v)
+	return block
+}
+
+// eacBlock returns the EAC block of the base, the multiplier, the table and the 3-bit index of the pixel i,
+// the indices are from the bit 47 column by column.
+func eacBlock(base, multiplier, table int, index func(i int) int) []byte {
+	v := uint64(base)<<56 | uint64(multiplier)<<52 | uint64(table)<<48
+	for i := 0; i < 16; i++ {
+		v |= uint64(index(i)) << uint(45-3*i)
+	}
+	block := make([]byte, 8)
+	binary.BigEndian.PutUint64(block, v)
+	return block
+}
+
+func TestDecodeETC2(t *testing.T) {
+	row := func(x, y int) int { return y }
+	tests := []struct {
+		name         string
+		block        []byte
+		punchthrough bool
+		pixel        func(x, y int) [4]byte
+	}{
+		// the red 8 and 0 of 4 bits, the tables 0 and 7, the left and the right subblocks.
+		{"individual", etcBlock(8<<60|7<<34, func(x, y int) int {
+			if x == 1 && y == 0 {
+				return 3
+			}
+			return 1
+		}), false, func(x, y int) [4]byte {
+			switch {
+			case x == 1 && y == 0:
+				return [4]byte{128, 0, 0, 255}
+			case x < 2:
+				return [4]byte{144, 8, 8, 255}
+			}
+			return [4]byte{183, 183, 183, 255}
+		}},
+		// the red 16 and the delta -4 of 5 bits, the top and the bottom subblocks.
+		{"differential", etcBlock(16<<59|4<<56|1<<33|1<<32, row), false, func(x, y int) [4]byte {
+			return [][4]byte{{134, 2, 2, 255}, {140, 8, 8, 255}, {97, 0, 0, 255}, {91, 0, 0, 255}}[y]
+		}},
+		// the red 3 and the delta -4 overflow, the colors 12, 0, 15 and 8, 8, 8 of 4 bits and the distance 5.
+		{"T", etcBlock(3<<59|4<<56|15<<48|8<<44|8<<40|8<<36|2<<34|1<<33|1<<32, row), false, func(x, y int) [4]byte {
+			return [][4]byte{{204, 0, 255, 255}, {168, 168, 168, 255}, {136, 136, 136, 255}, {104, 104, 104, 255}}[y]
+		}},
+		{"T punchthrough", etcBlock(3<<59|4<<56|15<<48|8<<44|8<<40|8<<36|2<<34|1<<32, row), true, func(x, y int) [4]byte {
+			return [][4]byte{{204, 0, 255, 255}, {168, 168, 168, 255}, {}, {104, 104, 104, 255}}[y]
+		}},
+		// the green 0 and the delta -4 overflow, the colors 8, 4, 0 and 2, 4, 6 of 4 bits,
+		// the distance 5 of the bits 1, 0 and the first color is not less than the second.
+		{"H", etcBlock(8<<59|2<<56|1<<50|2<<43|4<<39|6<<35|1<<34|1<<33, row), false, func(x, y int) [4]byte {
+			return [][4]byte{{168, 100, 32, 255}, {104, 36, 0, 255}, {66, 100, 134, 255}, {2, 36, 70, 255}}[y]
+		}},
+		// the blue 0 and the delta -4 overflow, the red is 255 horizontally and the green 255 vertically.
+		{"planar", etcBlock(1<<42|31<<34|1<<33|1<<32|127<<6, func(x, y int) int { return 0 }), false, func(x, y int) [4]byte {
+			ramp := []byte{0, 64, 128, 191}
+			return [4]byte{ramp[x], ramp[y], 0, 255}
+		}},
+		// the punchthrough differential mode, the index 0 is the base color and the index 2 is transparent.
+		{"differential punchthrough", etcBlock(16<<59|4<<56|1<<32, row), true, func(x, y int) [4]byte {
+			if y < 2 {
+				return [][4]byte{{132, 0, 0, 255}, {140, 8, 8, 255}}[y]
+			}
+			return [][4]byte{{}, {91, 0, 0, 255}}[y-2]
+		}},
+	}
+	out := make([]byte, 64)
+	for _, test := range tests {
+		decodeETC2(test.block, out, test.punchthrough)
+		pixels := rgba8(out)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if want := test.pixel(x, y); pixels[y*4+x] != want {
+					t.Errorf("%s: pixel (%d, %d) = %v, want %v", test.name, x, y, pixels[y*4+x], want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeETC2EAC(t *testing.T) {
+	// the base 128, the multiplier 2 and the table 13 of the modifiers -1, -2, -3, -10, 0, 1, 2, 9.
+	block := append(eacBlock(128, 2, 13, func(i int) int { return i % 8 }), make([]byte, 8)...)
+	alphas := [8]byte{126, 124, 122, 108, 128, 130, 132, 146}
+
+	out := make([]byte, 64)
+	decodeETC2EAC(block, out)
+	pixels := rgba8(out)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			// the RGB block of zeros is the individual mode of black and the modifier 2.
+			if want := [4]byte{2, 2, 2, alphas[(x*4+y)%8]}; pixels[y*4+x] != want {
+				t.Errorf("pixel (%d, %d) = %v, want %v", x, y, pixels[y*4+x], want)
+			}
+		}
+	}
+
+	copy(block, eacBlock(250, 1, 13, func(i int) int { return 7 }))
+	decodeETC2EAC(block, out)
+	if a := out[3]; a != 255 {
+		t.Errorf("clamped alpha = %d, want 255", a)
+	}
+}
+
+func TestDecodeEAC11(t *testing.T) {
+	index := func(i int) int { return i % 8 }
+	unsigned := func(v int) uint16 { return uint16(v<<5 | v>>6) }
+	signed := func(v int) uint16 {
+		if v < 0 {
+			return uint16(-(-v<<5 | -v>>5))
+		}
+		return uint16(v<<5 | v>>5)
+	}
+	tests := []struct {
+		name   string
+		block  []byte
+		signed bool
+		values [8]uint16
+	}{
+		// base*8+4 + modifier*multiplier*8
+		{"unsigned", eacBlock(128, 2, 13, index), false, [8]uint16{
+			unsigned(1012), unsigned(996), unsigned(980), unsigned(868),
+			unsigned(1028), unsigned(1044), unsigned(1060), unsigned(1172)}},
+		// the multiplier 0 adds the modifiers unscaled, clamped to 0.
+		{"unsigned multiplier 0", eacBlock(0, 0, 13, index), false, [8]uint16{
+			unsigned(3), unsigned(2), unsigned(1), 0, unsigned(4), unsigned(5), unsigned(6), unsigned(13)}},
+		{"unsigned clamped", eacBlock(255, 15, 0, index), false, [8]uint16{
+			unsigned(1684), unsigned(1324), unsigned(964), unsigned(244), 0xffff, 0xffff, 0xffff, 0xffff}},
+		// base*8 + modifier*multiplier*8 of the base -64.
+		{"signed", eacBlock(0xc0, 1, 13, index), true, [8]uint16{
+			signed(-520), signed(-528), signed(-536), signed(-592),
+			signed(-512), signed(-504), signed(-496), signed(-440)}},
+		// the base -128 is read as -127, clamped to -1023.
+		{"signed clamped", eacBlock(0x80, 2, 13, index), true, [8]uint16{
+			signed(-1023), signed(-1023), signed(-1023), signed(-1023),
+			signed(-1016), signed(-1000), signed(-984), signed(-872)}},
+	}
+	out := make([]byte, 32)
+	for _, test := range tests {
+		decodeEAC11(test.block, out, 2, test.signed)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				got := binary.BigEndian.Uint16(out[(y*4+x)*2:])
+				if want := test.values[(x*4+y)%8]; got != want {
+					t.Errorf("%s: pixel (%d, %d) = %#04x, want %#04x", test.name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeEACRG11(t *testing.T) {
+	block := append(eacBlock(128, 2, 13, func(i int) int { return 4 }), eacBlock(0, 0, 13, func(i int) int { return 7 })...)
+	out := make([]byte, 64)
+	FormatEACRG11.decode(block, out)
+	for i := 0; i < 16; i++ {
+		r, g := binary.BigEndian.Uint16(out[i*4:]), binary.BigEndian.Uint16(out[i*4+2:])
+		if r != 1028<<5|1028>>6 || g != 13<<5 {
+			t.Errorf("pixel %d = (%#04x, %#04x), want (%#04x, %#04x)", i, r, g, 1028<<5|1028>>6, 13<<5)
+		}
+	}
+
+	block = append(eacBlock(0xc0, 1, 13, func(i int) int { return 4 }), eacBlock(0x40, 1, 13, func(i int) int { return 4 })...)
+	FormatEACRG11Signed.decode(block, out)
+	for i := 0; i < 16; i++ {
+		r, g := int16(binary.BigEndian.Uint16(out[i*4:])), int16(binary.BigEndian.Uint16(out[i*4+2:]))
+		if want := int16(512<<5 | 512>>5); r != -want || g != want {
+			t.Errorf("signed pixel %d = (%d, %d), want (%d, %d)", i, r, g, -want, want)
+		}
+	}
+}
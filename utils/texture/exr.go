@@ -0,0 +1,360 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+func init() {
+	image.RegisterFormat("exr", "\x76\x2f\x31\x01", func(r io.Reader) (image.Image, error) { return DecodeEXR(r) }, configEXR)
+}
+
+const (
+	exrMagic = 20000630
+
+	exrNoCompression   = 0
+	exrRLECompression  = 1
+	exrZIPSCompression = 2 // zlib, one scanline per block
+	exrZIPCompression  = 3 // zlib, 16 scanlines per block
+
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+// exrChannel is a channel of an OpenEXR image.
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+func (c exrChannel) size() int {
+	if c.pixelType == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+// exrHeader is the part of the OpenEXR header needed to decode the pixels.
+type exrHeader struct {
+	channels    []exrChannel
+	compression byte
+	dataWindow  image.Rectangle // the max point is inclusive as in the file
+}
+
+func (h *exrHeader) width() int  { return h.dataWindow.Max.X - h.dataWindow.Min.X + 1 }
+func (h *exrHeader) height() int { return h.dataWindow.Max.Y - h.dataWindow.Min.Y + 1 }
+
+// linesPerBlock returns the number of the scanlines in a block of the compression.
+func (h *exrHeader) linesPerBlock() int {
+	if h.compression == exrZIPCompression {
+		return 16
+	}
+	return 1
+}
+
+func readEXRHeader(r io.Reader) (*exrHeader, error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(head[:]) != exrMagic {
+		return nil, errors.New("exr: not an OpenEXR image")
+	}
+	version := binary.LittleEndian.Uint32(head[4:])
+	if version&0xff != 2 {
+		return nil, fmt.Errorf("exr: unsupported version %d", version&0xff)
+	}
+	// bit 9 is the tiled flag, bits 11 and 12 are the deep data and the multi-part flags.
+	if version&0x200 != 0 {
+		return nil, errors.New("exr: tiled images are not supported")
+	}
+	if version&0x1800 != 0 {
+		return nil, errors.New("exr: deep and multi-part images are not supported")
+	}
+
+	hdr := &exrHeader{}
+	hasWindow := false
+	for {
+		name, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+		typ, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("exr: invalid size of attribute %q", name)
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == "channels" && typ == "chlist":
+			if hdr.channels, err = parseEXRChannels(value); err != nil {
+				return nil, err
+			}
+		case name == "compression" && typ == "compression" && len(value) == 1:
+			hdr.compression = value[0]
+		case name == "dataWindow" && typ == "box2i" && len(value) == 16:
+			v := func(i int) int { return int(int32(binary.LittleEndian.Uint32(value[i*4:]))) }
+			hdr.dataWindow = image.Rect(v(0), v(1), v(2), v(3))
+			hasWindow = true
+		}
+	}
+
+	if !hasWindow || hdr.width() <= 0 || hdr.height() <= 0 {
+		return nil, errors.New("exr: missing or empty data window")
+	}
+	switch hdr.compression {
+	case exrNoCompression, exrRLECompression, exrZIPSCompression, exrZIPCompression:
+	default:
+		return nil, fmt.Errorf("exr: unsupported compression %d", hdr.compression)
+	}
+	return hdr, nil
+}
+
+func parseEXRChannels(value []byte) ([]exrChannel, error) {
+	var channels []exrChannel
+	for len(value) > 0 && value[0] != 0 {
+		i := bytes.IndexByte(value, 0)
+		if i < 0 || i+17 > len(value) {
+			return nil, errors.New("exr: invalid channel list")
+		}
+		c := exrChannel{
+			name:      string(value[:i]),
+			pixelType: int32(binary.LittleEndian.Uint32(value[i+1:])),
+		}
+		xSampling := binary.LittleEndian.Uint32(value[i+9:])
+		ySampling := binary.LittleEndian.Uint32(value[i+13:])
+		if xSampling != 1 || ySampling != 1 {
+			return nil, fmt.Errorf("exr: subsampled channel %q is not supported", c.name)
+		}
+		if c.pixelType < exrUint || c.pixelType > exrFloat {
+			return nil, fmt.Errorf("exr: invalid pixel type %d of channel %q", c.pixelType, c.name)
+		}
+		channels = append(channels, c)
+		value = value[i+17:]
+	}
+	return channels, nil
+}
+
+func readCString(r io.Reader) (string, error) {
+	var s []byte
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return "", err
+		}
+		if b[0] == 0 {
+			return string(s), nil
+		}
+		s = append(s, b[0])
+	}
+}
+
+func configEXR(r io.Reader) (image.Config, error) {
+	hdr, err := readEXRHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBA64Model, Width: hdr.width(), Height: hdr.height()}, nil
+}
+
+// DecodeEXR decodes a scanline OpenEXR image which is uncompressed, or compressed by RLE, ZIPS or ZIP.
+// The R, G and B channels are decoded, a luminance only image is decoded from its Y channel as gray.
+func DecodeEXR(r io.Reader) (*FloatImage, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bytes.NewReader(data)
+	hdr, err := readEXRHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// the component of each channel, 3 for the luminance and -1 if the channel is not decoded.
+	components := make([]int, len(hdr.channels))
+	luminance := true
+	for i, c := range hdr.channels {
+		switch c.name {
+		case "R", "G", "B":
+			components[i] = strings.Index("RGB", c.name)
+			luminance = false
+		case "Y":
+			components[i] = 3
+		default:
+			components[i] = -1
+		}
+	}
+
+	w, h := hdr.width(), hdr.height()
+	if err := checkFloatImageSize(w, h); err != nil {
+		return nil, fmt.Errorf("exr: %v", err)
+	}
+	lines := hdr.linesPerBlock()
+	blocks := (h + lines - 1) / lines
+	if blocks > br.Len()/8 {
+		return nil, fmt.Errorf("exr: %d bytes for the offsets of %d blocks", br.Len(), blocks)
+	}
+	offsets := make([]uint64, blocks)
+	if err := binary.Read(br, binary.LittleEndian, offsets); err != nil {
+		return nil, err
+	}
+
+	rowSize := 0
+	for _, c := range hdr.channels {
+		rowSize += w * c.size()
+	}
+
+	img := NewFloatImage(image.Rect(0, 0, w, h))
+	for _, offset := range offsets {
+		if offset > uint64(len(data)) || uint64(len(data))-offset < 8 {
+			return nil, errors.New("exr: invalid block offset")
+		}
+		y := int(int32(binary.LittleEndian.Uint32(data[offset:]))) - hdr.dataWindow.Min.Y
+		size := uint64(binary.LittleEndian.Uint32(data[offset+4:]))
+		if y < 0 || y >= h || offset+8+size > uint64(len(data)) {
+			return nil, errors.New("exr: invalid block")
+		}
+		n := lines
+		if y+n > h {
+			n = h - y
+		}
+
+		block, err := decompressEXR(hdr.compression, data[offset+8:offset+8+size], n*rowSize)
+		if err != nil {
+			return nil, err
+		}
+		for line := 0; line < n; line++ {
+			row := block[line*rowSize:]
+			for i, c := range hdr.channels {
+				for x := 0; x < w; x++ {
+					v := exrValue(row[x*c.size():], c.pixelType)
+					switch comp := components[i]; {
+					case comp == 3 && luminance:
+						img.SetRGB(x, y+line, v, v, v)
+					case comp >= 0 && comp < 3:
+						img.Pix[img.PixOffset(x, y+line)+comp] = v
+					}
+				}
+				row = row[w*c.size():]
+			}
+		}
+	}
+	return img, nil
+}
+
+func exrValue(b []byte, pixelType int32) float32 {
+	switch pixelType {
+	case exrHalf:
+		return halfToFloat(binary.LittleEndian.Uint16(b))
+	case exrFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	return float32(binary.LittleEndian.Uint32(b))
+}
+
+// decompressEXR decompresses a block to its size.
+func decompressEXR(compression byte, data []byte, size int) ([]byte, error) {
+	// the blocks which would not be smaller compressed are stored uncompressed.
+	if compression == exrNoCompression || len(data) == size {
+		if len(data) != size {
+			return nil, fmt.Errorf("exr: uncompressed block size %d, expect %d", len(data), size)
+		}
+		return data, nil
+	}
+
+	var buf []byte
+	switch compression {
+	case exrRLECompression:
+		for i := 0; i < len(data); {
+			count := int(int8(data[i]))
+			if count < 0 {
+				if i+1-count > len(data) {
+					return nil, errors.New("exr: bad RLE data")
+				}
+				buf = append(buf, data[i+1:i+1-count]...)
+				i += 1 - count
+				continue
+			}
+			if i+1 >= len(data) {
+				return nil, errors.New("exr: bad RLE data")
+			}
+			for j := 0; j <= count; j++ {
+				buf = append(buf, data[i+1])
+			}
+			i += 2
+		}
+	default:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		if buf, err = ioutil.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	if len(buf) != size {
+		return nil, fmt.Errorf("exr: decompressed block size %d, expect %d", len(buf), size)
+	}
+
+	// undo the predictor, then the interleaving of the bytes.
+	for i := 1; i < len(buf); i++ {
+		buf[i] = buf[i-1] + buf[i] - 128
+	}
+	out := make([]byte, len(buf))
+	half := (len(buf) + 1) / 2
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = buf[i/2]
+		} else {
+			out[i] = buf[half+i/2]
+		}
+	}
+	return out, nil
+}
+
+// halfToFloat converts the IEEE 754 half precision float to float32.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := int32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// subnormal, normalize it.
+		exp = 1
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		mant &= 0x3ff
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | mant<<13)
+	}
+	return math.Float32frombits(sign | uint32(exp+127-15)<<23 | mant<<13)
+}
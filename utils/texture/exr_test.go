@@ -0,0 +1,121 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEXR(t *testing.T) {
+	// R is float, G and B are half, and A is not decoded.
+	want := make([][]float32, 2)
+	for x := 0; x < 8; x++ {
+		want[0] = append(want[0], float32(x), 0.5, 0)
+		want[1] = append(want[1], -1, float32(x)*0.25, 0)
+	}
+	for _, file := range []string{"none.exr", "rle.exr", "zips.exr", "zip.exr"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != "exr" {
+				t.Errorf("format %q", format)
+			}
+			// the pixels are at the origin, rather than at the min point of the data window.
+			compareFloatImage(t, img.(*FloatImage), want)
+		})
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "luminance.exr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeEXR(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFloatImage(t, img, [][]float32{{0.5, 0.5, 0.5, 2, 2, 2}})
+}
+
+func TestDecodeEXRInvalid(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "none.exr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the offset table follows the header, which ends with the empty attribute name.
+	table := bytes.Index(data, []byte("screenWindowWidth\x00float\x00\x04\x00\x00\x00")) + 33
+
+	window := bytes.Index(data, []byte("dataWindow\x00box2i\x00")) + 21
+	huge := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(huge[window+8:], 1<<30)
+	binary.LittleEndian.PutUint32(huge[window+12:], 1<<30)
+
+	tall := append([]byte{}, data[:table]...)
+	binary.LittleEndian.PutUint32(tall[window+12:], 100000)
+
+	offset := append([]byte{}, data...)
+	binary.LittleEndian.PutUint64(offset[table:], math.MaxUint64-3)
+
+	size := append([]byte{}, data...)
+	block := binary.LittleEndian.Uint64(size[table:])
+	binary.LittleEndian.PutUint32(size[block+4:], 10)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"huge", huge, "out of range"},
+		{"offsets", tall, "for the offsets of"},
+		{"offset", offset, "invalid block offset"},
+		{"block size", size, "uncompressed block size 10"},
+		{"truncated", data[:len(data)-4], "invalid block"},
+		{"magic", []byte("\x76\x2f\x31\x02\x02\x00\x00\x00"), "not an OpenEXR image"},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeEXR(bytes.NewReader(tt.data)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	tests := []struct {
+		h    uint16
+		want float32
+	}{
+		{0x0000, 0},
+		{0x3c00, 1},
+		{0xc000, -2},
+		{0x3800, 0.5},
+		{0x3555, 0.333251953125},
+		{0x7bff, 65504},
+		{0x0400, 1.0 / (1 << 14)}, // the smallest normal
+		{0x0001, 1.0 / (1 << 24)}, // the smallest subnormal
+		{0x03ff, 1023.0 / (1 << 24)},
+		{0x8001, -1.0 / (1 << 24)},
+		{0x7c00, float32(math.Inf(1))},
+		{0xfc00, float32(math.Inf(-1))},
+	}
+	for _, tt := range tests {
+		if got := halfToFloat(tt.h); got != tt.want {
+			t.Errorf("halfToFloat(0x%04x) = %v, want %v", tt.h, got, tt.want)
+		}
+	}
+	if got := halfToFloat(0x8000); got != 0 || !math.Signbit(float64(got)) {
+		t.Errorf("halfToFloat(0x8000) = %v, want -0", got)
+	}
+	if got := halfToFloat(0x7e00); got == got {
+		t.Errorf("halfToFloat(0x7e00) = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,401 @@
+package texture
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// PixelFormat is the layout of the pixel data uploaded to a texture.
+type PixelFormat struct {
+	InternalFormat int32  // the format the texture is stored in, e.g. gl.RGB8
+	Format         uint32 // the format of the pixel data, e.g. gl.RGB
+	Type           uint32 // the type of the components, e.g. gl.UNSIGNED_BYTE
+	Size           int    // the bytes per pixel
+	// Swizzle maps the stored components to the components read by the shaders,
+	// so the grayscale textures are read as gray rather than red. It is zero if not needed.
+	Swizzle [4]int32
+}
+
+var (
+	FormatR8 = PixelFormat{InternalFormat: gl.R8, Format: gl.RED, Type: gl.UNSIGNED_BYTE, Size: 1,
+		Swizzle: [4]int32{gl.RED, gl.RED, gl.RED, gl.ONE}}
+	FormatRG8 = PixelFormat{InternalFormat: gl.RG8, Format: gl.RG, Type: gl.UNSIGNED_BYTE, Size: 2,
+		Swizzle: [4]int32{gl.RED, gl.RED, gl.RED, gl.GREEN}}
+	FormatRGB8  = PixelFormat{InternalFormat: gl.RGB8, Format: gl.RGB, Type: gl.UNSIGNED_BYTE, Size: 3}
+	FormatRGBA8 = PixelFormat{InternalFormat: gl.RGBA8, Format: gl.RGBA, Type: gl.UNSIGNED_BYTE, Size: 4}
+	FormatR16   = PixelFormat{InternalFormat: gl.R16, Format: gl.RED, Type: gl.UNSIGNED_SHORT, Size: 2,
+		Swizzle: [4]int32{gl.RED, gl.RED, gl.RED, gl.ONE}}
+	FormatRGB16  = PixelFormat{InternalFormat: gl.RGB16, Format: gl.RGB, Type: gl.UNSIGNED_SHORT, Size: 6}
+	FormatRGBA16 = PixelFormat{InternalFormat: gl.RGBA16, Format: gl.RGBA, Type: gl.UNSIGNED_SHORT, Size: 8}
+	FormatRGB16F = PixelFormat{InternalFormat: gl.RGB16F, Format: gl.RGB, Type: gl.FLOAT, Size: 12}
+	FormatRGB32F = PixelFormat{InternalFormat: gl.RGB32F, Format: gl.RGB, Type: gl.FLOAT, Size: 12}
+)
+
+// Conversion selects the pixel format the loaded images are uploaded with.
+type Conversion int
+
+const (
+	// ConvertNone preserves the pixel format of the source image:
+	// the grayscale images are uploaded as R8 or R16, the gray images with alpha as RG8,
+	// the opaque images as RGB8 or RGB16 and the others as RGBA8 or RGBA16.
+	ConvertNone Conversion = iota
+	// ConvertRGBA converts all the images to RGBA8.
+	ConvertRGBA
+	// ConvertRGB converts all the images to RGB8, the alpha channel is dropped.
+	ConvertRGB
+)
+
+// pixels is the tightly packed pixel data of an image in the layout it is uploaded with,
+// the 16-bit components are big endian as in the image package, and so are the bits of the float components.
+type pixels struct {
+	Pix           []byte
+	Width, Height int
+	format        PixelFormat
+	premultiplied bool // the color components are premultiplied by alpha, as in image.RGBA
+}
+
+// newPixels converts the image to the pixel data of the format selected by the conversion.
+func newPixels(img image.Image, conv Conversion) *pixels {
+	switch conv {
+	case ConvertRGBA:
+		return rgbaPixels(toRGBA(img))
+	case ConvertRGB:
+		return rgbaPixels(toRGBA(img)).dropAlpha()
+	}
+
+	switch img := img.(type) {
+	case *image.Gray:
+		return copyPixels(img.Pix, img.Stride, img.Rect, FormatR8, false)
+	case *image.Gray16:
+		return copyPixels(img.Pix, img.Stride, img.Rect, FormatR16, false)
+	case *image.NRGBA:
+		p := copyPixels(img.Pix, img.Stride, img.Rect, FormatRGBA8, false)
+		if p.gray() {
+			if img.Opaque() {
+				return p.keep(0)
+			}
+			return p.keep(0, 3)
+		}
+		if img.Opaque() {
+			return p.dropAlpha()
+		}
+		return p
+	case *image.RGBA:
+		p := rgbaPixels(img)
+		if img.Opaque() {
+			return p.dropAlpha()
+		}
+		return p
+	case *image.NRGBA64:
+		p := copyPixels(img.Pix, img.Stride, img.Rect, FormatRGBA16, false)
+		if img.Opaque() {
+			return p.dropAlpha()
+		}
+		return p
+	case *image.RGBA64:
+		p := copyPixels(img.Pix, img.Stride, img.Rect, FormatRGBA16, true)
+		if img.Opaque() {
+			return p.dropAlpha()
+		}
+		return p
+	case *FloatImage:
+		return floatPixels(img)
+	}
+
+	// the other images, e.g. *image.YCbCr and *image.Paletted, are converted to RGBA first.
+	rgba := toRGBA(img)
+	p := rgbaPixels(rgba)
+	if rgba.Opaque() {
+		return p.dropAlpha()
+	}
+	return p
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+func rgbaPixels(img *image.RGBA) *pixels {
+	return copyPixels(img.Pix, img.Stride, img.Rect, FormatRGBA8, true)
+}
+
+func floatPixels(img *FloatImage) *pixels {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	p := &pixels{
+		Pix:    make([]byte, w*h*FormatRGB16F.Size),
+		Width:  w,
+		Height: h,
+		format: FormatRGB16F,
+	}
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*3]
+		for i, v := range row {
+			binary.BigEndian.PutUint32(p.Pix[(y*w*3+i)*4:], math.Float32bits(v))
+		}
+	}
+	return p
+}
+
+// copyPixels copies the rows of the rectangle r of the pixel data with the stride into a tightly packed buffer.
+func copyPixels(pix []byte, stride int, r image.Rectangle, format PixelFormat, premultiplied bool) *pixels {
+	w, h := r.Dx(), r.Dy()
+	p := &pixels{
+		Pix:           make([]byte, w*h*format.Size),
+		Width:         w,
+		Height:        h,
+		format:        format,
+		premultiplied: premultiplied,
+	}
+	row := w * format.Size
+	for y := 0; y < h; y++ {
+		copy(p.Pix[y*row:(y+1)*row], pix[y*stride:])
+	}
+	return p
+}
+
+// components returns the number of the components and the bytes per component.
+func (p *pixels) components() (int, int) {
+	switch p.format.Type {
+	case gl.UNSIGNED_SHORT, gl.SHORT:
+		return p.format.Size / 2, 2
+	case gl.FLOAT:
+		return p.format.Size / 4, 4
+	}
+	return p.format.Size, 1
+}
+
+// gray reports whether the red, green and blue components of all the RGBA pixels are equal.
+func (p *pixels) gray() bool {
+	n, c := p.components()
+	if n != 4 {
+		return false
+	}
+	for i := 0; i < len(p.Pix); i += p.format.Size {
+		for j := 0; j < c; j++ {
+			if p.Pix[i+j] != p.Pix[i+c+j] || p.Pix[i+j] != p.Pix[i+2*c+j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dropAlpha returns the RGB pixels of the RGBA pixels.
+func (p *pixels) dropAlpha() *pixels {
+	return p.keep(0, 1, 2)
+}
+
+// keep returns the pixels of the components listed, in the same component size.
+func (p *pixels) keep(channels ...int) *pixels {
+	_, c := p.components()
+
+	var format PixelFormat
+	switch {
+	case c == 1 && len(channels) == 1:
+		format = FormatR8
+	case c == 1 && len(channels) == 2:
+		format = FormatRG8
+	case c == 1 && len(channels) == 3:
+		format = FormatRGB8
+	case c == 1:
+		format = FormatRGBA8
+	case len(channels) == 1:
+		format = FormatR16
+	case len(channels) == 3:
+		format = FormatRGB16
+	default:
+		format = FormatRGBA16
+	}
+
+	q := &pixels{
+		Pix:    make([]byte, p.Width*p.Height*format.Size),
+		Width:  p.Width,
+		Height: p.Height,
+		format: format,
+		// only the pixels keeping the alpha keep it premultiplied.
+		premultiplied: p.premultiplied && len(channels) == 4,
+	}
+	for i, j := 0, 0; i < len(p.Pix); i, j = i+p.format.Size, j+format.Size {
+		for k, ch := range channels {
+			copy(q.Pix[j+k*c:j+(k+1)*c], p.Pix[i+ch*c:i+(ch+1)*c])
+		}
+	}
+	return q
+}
+
+// image returns the pixels as an image of the image package.
+// The signed components are offset to unsigned, so zero is the middle value.
+func (p *pixels) image() image.Image {
+	r := image.Rect(0, 0, p.Width, p.Height)
+	n, c := p.components()
+	if p.format.Type == gl.BYTE || p.format.Type == gl.SHORT {
+		q := *p
+		q.Pix = make([]byte, len(p.Pix))
+		for i, b := range p.Pix {
+			if i%c == 0 {
+				b ^= 0x80 // the sign bit of the big endian component
+			}
+			q.Pix[i] = b
+		}
+		q.format.Type = gl.UNSIGNED_BYTE
+		if c == 2 {
+			q.format.Type = gl.UNSIGNED_SHORT
+		}
+		p = &q
+	}
+	if c == 4 {
+		img := NewFloatImage(r)
+		for i := range img.Pix {
+			img.Pix[i] = math.Float32frombits(binary.BigEndian.Uint32(p.Pix[i*4:]))
+		}
+		return img
+	}
+	if n == 1 && c == 1 {
+		return &image.Gray{Pix: p.Pix, Stride: p.Width, Rect: r}
+	}
+	if n == 1 {
+		return &image.Gray16{Pix: p.Pix, Stride: p.Width * 2, Rect: r}
+	}
+
+	// expand to 4 components, the gray value is the red, green and blue, and the alpha defaults to opaque.
+	// The two components without a swizzle are red and green rather than gray and alpha.
+	rg := n == 2 && p.format.Swizzle == [4]int32{}
+	pix := p.Pix
+	if n != 4 {
+		pix = make([]byte, p.Width*p.Height*4*c)
+	}
+	for i, j := 0, 0; n != 4 && i < len(p.Pix); i, j = i+p.format.Size, j+4*c {
+		for k := 0; k < 4; k++ {
+			src := k
+			switch {
+			case rg && k == 2:
+				src = -2
+			case rg && k == 3:
+				src = -1
+			case rg:
+			case n == 2 && k < 3:
+				src = 0
+			case n == 2:
+				src = 1
+			case n == 3 && k == 3:
+				src = -1
+			}
+			for b := 0; b < c; b++ {
+				if src == -2 {
+					pix[j+k*c+b] = 0
+				} else if src < 0 {
+					pix[j+k*c+b] = 0xff
+				} else {
+					pix[j+k*c+b] = p.Pix[i+src*c+b]
+				}
+			}
+		}
+	}
+
+	switch {
+	case c == 1 && p.premultiplied:
+		return &image.RGBA{Pix: pix, Stride: p.Width * 4, Rect: r}
+	case c == 1:
+		return &image.NRGBA{Pix: pix, Stride: p.Width * 4, Rect: r}
+	case p.premultiplied:
+		return &image.RGBA64{Pix: pix, Stride: p.Width * 8, Rect: r}
+	}
+	return &image.NRGBA64{Pix: pix, Stride: p.Width * 8, Rect: r}
+}
+
+// component returns the i-th component of the pixel data,
+// the unsigned integer components are normalized to [0, 1].
+func (p *pixels) component(i int) float64 {
+	switch p.format.Type {
+	case gl.UNSIGNED_SHORT:
+		return float64(binary.BigEndian.Uint16(p.Pix[i*2:])) / 0xffff
+	case gl.FLOAT:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(p.Pix[i*4:])))
+	}
+	return float64(p.Pix[i]) / 0xff
+}
+
+// setComponent sets the i-th component of the pixel data, the integer components are clamped to [0, 1].
+func (p *pixels) setComponent(i int, v float64) {
+	if p.format.Type == gl.FLOAT {
+		binary.BigEndian.PutUint32(p.Pix[i*4:], math.Float32bits(float32(v)))
+		return
+	}
+	v = math.Max(0, math.Min(1, v))
+	if p.format.Type == gl.UNSIGNED_SHORT {
+		binary.BigEndian.PutUint16(p.Pix[i*2:], uint16(v*0xffff+0.5))
+		return
+	}
+	p.Pix[i] = byte(v*0xff + 0.5)
+}
+
+// rgba returns the pixels as an 8-bit RGBA image, the float pixels are clamped to [0, 1].
+func (p *pixels) rgba() *image.RGBA {
+	return toRGBA(p.image())
+}
+
+// glData returns the pixel data in the native byte order the GL reads the components in.
+func (p *pixels) glData() interface{} {
+	switch p.format.Type {
+	case gl.UNSIGNED_SHORT, gl.SHORT:
+		pix := make([]uint16, len(p.Pix)/2)
+		for i := range pix {
+			pix[i] = binary.BigEndian.Uint16(p.Pix[2*i:])
+		}
+		return pix
+	case gl.FLOAT:
+		pix := make([]float32, len(p.Pix)/4)
+		for i := range pix {
+			pix[i] = math.Float32frombits(binary.BigEndian.Uint32(p.Pix[4*i:]))
+		}
+		return pix
+	}
+	return p.Pix
+}
+
+// texImage2D uploads the pixels to the level of the texture bound to the target,
+// the target is gl.TEXTURE_2D or a face of a cube map.
+func (p *pixels) texImage2D(target uint32, level int32) {
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, unpackAlignment(p.Width*p.format.Size))
+	gl.TexImage2D(target, level, p.format.InternalFormat, int32(p.Width), int32(p.Height),
+		0, p.format.Format, p.format.Type, gl.Ptr(p.glData()))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+}
+
+// texImage3D uploads the pixels of the depth layers stacked vertically to the level of the texture bound to the target,
+// the target is gl.TEXTURE_2D_ARRAY or gl.TEXTURE_3D.
+func (p *pixels) texImage3D(target uint32, level int32, depth int) {
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, unpackAlignment(p.Width*p.format.Size))
+	gl.TexImage3D(target, level, p.format.InternalFormat, int32(p.Width), int32(p.Height/depth), int32(depth),
+		0, p.format.Format, p.format.Type, gl.Ptr(p.glData()))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+}
+
+// setSwizzle sets the swizzle of the format to the texture bound to the target, the identity if it has none.
+func setSwizzle(target uint32, format PixelFormat) {
+	swizzle := format.Swizzle
+	if swizzle == [4]int32{} {
+		swizzle = [4]int32{gl.RED, gl.GREEN, gl.BLUE, gl.ALPHA}
+	}
+	gl.TexParameteriv(target, gl.TEXTURE_SWIZZLE_RGBA, &swizzle[0])
+}
+
+// unpackAlignment returns the largest alignment supported by the GL the rows of the size are aligned to.
+func unpackAlignment(rowSize int) int32 {
+	for _, align := range []int{8, 4, 2} {
+		if rowSize%align == 0 {
+			return int32(align)
+		}
+	}
+	return 1
+}
@@ -0,0 +1,158 @@
+package texture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestNewPixels(t *testing.T) {
+	r := image.Rect(0, 0, 2, 1)
+
+	gray := image.NewGray(r)
+	gray.Pix = []byte{0x10, 0x20}
+	gray16 := image.NewGray16(r)
+	gray16.Pix = []byte{0x12, 0x34, 0x56, 0x78}
+
+	nrgba := func(pix ...byte) *image.NRGBA {
+		img := image.NewNRGBA(r)
+		copy(img.Pix, pix)
+		return img
+	}
+	rgba := func(pix ...byte) *image.RGBA {
+		img := image.NewRGBA(r)
+		copy(img.Pix, pix)
+		return img
+	}
+	nrgba64 := image.NewNRGBA64(r)
+	nrgba64.SetNRGBA64(0, 0, color.NRGBA64{0x0102, 0x0304, 0x0506, 0xffff})
+	nrgba64.SetNRGBA64(1, 0, color.NRGBA64{0x0708, 0x090a, 0x0b0c, 0xffff})
+	rgba64 := image.NewRGBA64(r)
+	rgba64.SetRGBA64(0, 0, color.RGBA64{0x0102, 0x0304, 0x0506, 0x8000})
+
+	ycbcr := image.NewYCbCr(r, image.YCbCrSubsampleRatio444)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i], ycbcr.Cb[i], ycbcr.Cr[i] = 0x80, 0x80, 0x80
+	}
+	paletted := image.NewPaletted(r, color.Palette{color.NRGBA{0xff, 0, 0, 0xff}, color.NRGBA{0, 0, 0, 0}})
+	paletted.Pix = []byte{0, 1}
+
+	float := NewFloatImage(r)
+	copy(float.Pix, []float32{1, 0.5, -2, 0, 0, 65504})
+	floatPix := []byte{
+		0x3f, 0x80, 0, 0, 0x3f, 0, 0, 0, 0xc0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0x47, 0x7f, 0xe0, 0,
+	}
+
+	tests := []struct {
+		name          string
+		img           image.Image
+		conv          Conversion
+		format        PixelFormat
+		pix           []byte
+		premultiplied bool
+	}{
+		{"Gray", gray, ConvertNone, FormatR8, []byte{0x10, 0x20}, false},
+		{"Gray16", gray16, ConvertNone, FormatR16, []byte{0x12, 0x34, 0x56, 0x78}, false},
+		{"NRGBA gray opaque", nrgba(1, 1, 1, 255, 2, 2, 2, 255), ConvertNone, FormatR8, []byte{1, 2}, false},
+		{"NRGBA gray alpha", nrgba(1, 1, 1, 255, 2, 2, 2, 0), ConvertNone, FormatRG8, []byte{1, 255, 2, 0}, false},
+		{"NRGBA opaque", nrgba(1, 2, 3, 255, 4, 5, 6, 255), ConvertNone, FormatRGB8, []byte{1, 2, 3, 4, 5, 6}, false},
+		{"NRGBA alpha", nrgba(1, 2, 3, 4, 5, 6, 7, 8), ConvertNone, FormatRGBA8, []byte{1, 2, 3, 4, 5, 6, 7, 8}, false},
+		{"RGBA opaque", rgba(1, 2, 3, 255, 4, 5, 6, 255), ConvertNone, FormatRGB8, []byte{1, 2, 3, 4, 5, 6}, false},
+		{"RGBA alpha", rgba(1, 2, 3, 4, 0, 0, 0, 0), ConvertNone, FormatRGBA8, []byte{1, 2, 3, 4, 0, 0, 0, 0}, true},
+		{"NRGBA64 opaque", nrgba64, ConvertNone, FormatRGB16, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, false},
+		{"RGBA64 alpha", rgba64, ConvertNone, FormatRGBA16, []byte{1, 2, 3, 4, 5, 6, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0}, true},
+		{"YCbCr", ycbcr, ConvertNone, FormatRGB8, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80}, false},
+		{"Paletted", paletted, ConvertNone, FormatRGBA8, []byte{0xff, 0, 0, 0xff, 0, 0, 0, 0}, true},
+		{"FloatImage", float, ConvertNone, FormatRGB16F, floatPix, false},
+		{"ConvertRGBA", gray, ConvertRGBA, FormatRGBA8, []byte{0x10, 0x10, 0x10, 0xff, 0x20, 0x20, 0x20, 0xff}, true},
+		{"ConvertRGB", nrgba(1, 2, 3, 4, 5, 6, 7, 8), ConvertRGB, FormatRGB8, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPixels(tt.img, tt.conv)
+			if p.format != tt.format {
+				t.Errorf("format %+v, want %+v", p.format, tt.format)
+			}
+			if p.Width != 2 || p.Height != 1 {
+				t.Errorf("size %dx%d, want 2x1", p.Width, p.Height)
+			}
+			if len(p.Pix) != 2*tt.format.Size {
+				t.Errorf("%d bytes, want %d", len(p.Pix), 2*tt.format.Size)
+			}
+			if tt.pix != nil && !bytes.Equal(p.Pix, tt.pix) {
+				t.Errorf("pix %v, want %v", p.Pix, tt.pix)
+			}
+			if p.premultiplied != tt.premultiplied {
+				t.Errorf("premultiplied %v, want %v", p.premultiplied, tt.premultiplied)
+			}
+		})
+	}
+}
+
+func TestNewPixelsSubImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 3))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+	// the rows of the sub image are copied without the stride.
+	p := newPixels(img.SubImage(image.Rect(1, 1, 3, 3)), ConvertNone)
+	if want := []byte{5, 6, 9, 10}; !bytes.Equal(p.Pix, want) {
+		t.Errorf("pix %v, want %v", p.Pix, want)
+	}
+}
+
+func TestPixelsComponent(t *testing.T) {
+	p := &pixels{Pix: make([]byte, 12), Width: 1, Height: 1, format: FormatRGB16F}
+	for i, v := range []float64{-1, 0.25, 1000} {
+		p.setComponent(i, v)
+		if got := p.component(i); got != v {
+			t.Errorf("float component %d = %v, want %v", i, got, v)
+		}
+	}
+
+	p = &pixels{Pix: make([]byte, 6), Width: 1, Height: 1, format: FormatRGB16}
+	for i, v := range []float64{-1, 0.5, 2} {
+		p.setComponent(i, v)
+		want := math.Max(0, math.Min(1, v))
+		if got := p.component(i); math.Abs(got-want) > 1.0/0xffff {
+			t.Errorf("16-bit component %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestUnpackAlignment(t *testing.T) {
+	tests := []struct {
+		format PixelFormat
+		width  int
+		want   int32
+	}{
+		{FormatR8, 1, 1},
+		{FormatR8, 2, 2},
+		{FormatR8, 3, 1},
+		{FormatR8, 4, 4},
+		{FormatR8, 16, 8},
+		{FormatRG8, 1, 2},
+		{FormatRG8, 3, 2},
+		{FormatRGB8, 1, 1},
+		{FormatRGB8, 3, 1},
+		{FormatRGB8, 4, 4},
+		{FormatRGB8, 5, 1},
+		{FormatRGBA8, 1, 4},
+		{FormatRGBA8, 3, 4},
+		{FormatRGBA8, 2, 8},
+		{FormatR16, 1, 2},
+		{FormatRGB16, 1, 2},
+		{FormatRGB16, 3, 2},
+		{FormatRGB16F, 1, 4},
+		{FormatRGB16F, 3, 4},
+		{FormatRGB16F, 2, 8},
+	}
+	for _, tt := range tests {
+		if got := unpackAlignment(tt.width * tt.format.Size); got != tt.want {
+			t.Errorf("%d pixels of %d bytes: alignment %d, want %d", tt.width, tt.format.Size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package texture
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	image.RegisterFormat("hdr", "#?RADIANCE", func(r io.Reader) (image.Image, error) { return DecodeRadiance(r) }, configRadiance)
+	image.RegisterFormat("hdr", "#?RGBE", func(r io.Reader) (image.Image, error) { return DecodeRadiance(r) }, configRadiance)
+}
+
+// FloatImage is an image of linear RGB float32 pixels, as decoded from the HDR formats.
+// As an image.Image, the colors are clamped to [0, 1] and opaque.
+type FloatImage struct {
+	Pix    []float32 // the R, G, B components of the pixels, row by row from the top
+	Stride int       // the number of the components between two vertically adjacent pixels
+	Rect   image.Rectangle
+}
+
+// maxFloatPixels is the largest number of the pixels of the decoded HDR images, 16384x8192,
+// so a corrupted header can not allocate gigabytes before any pixel data is read.
+const maxFloatPixels = 16384 * 8192
+
+// checkFloatImageSize checks the size of the image to decode is not too large.
+func checkFloatImageSize(w, h int) error {
+	if w <= 0 || h <= 0 || w > maxFloatPixels/h {
+		return fmt.Errorf("image size %dx%d is out of range", w, h)
+	}
+	return nil
+}
+
+// NewFloatImage returns a new float image of the bounds.
+func NewFloatImage(r image.Rectangle) *FloatImage {
+	return &FloatImage{
+		Pix:    make([]float32, r.Dx()*r.Dy()*3),
+		Stride: r.Dx() * 3,
+		Rect:   r,
+	}
+}
+
+func (p *FloatImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (p *FloatImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *FloatImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	r, g, b := p.RGBAt(x, y)
+	clamp := func(v float32) uint16 {
+		if v <= 0 || v != v {
+			return 0
+		}
+		if v >= 1 {
+			return 0xffff
+		}
+		return uint16(v*0xffff + 0.5)
+	}
+	return color.RGBA64{R: clamp(r), G: clamp(g), B: clamp(b), A: 0xffff}
+}
+
+// Opaque reports whether the image is fully opaque, which is always true.
+func (p *FloatImage) Opaque() bool {
+	return true
+}
+
+// PixOffset returns the index of the first component of the pixel at (x, y).
+func (p *FloatImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+}
+
+// RGBAt returns the components of the pixel at (x, y).
+func (p *FloatImage) RGBAt(x, y int) (r, g, b float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return 0, 0, 0
+	}
+	i := p.PixOffset(x, y)
+	return p.Pix[i], p.Pix[i+1], p.Pix[i+2]
+}
+
+// SetRGB sets the components of the pixel at (x, y).
+func (p *FloatImage) SetRGB(x, y int, r, g, b float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i], p.Pix[i+1], p.Pix[i+2] = r, g, b
+}
+
+// radianceHeader is the header of a Radiance RGBE image.
+type radianceHeader struct {
+	width, height int
+	flipV         bool // the scanlines are stored from the bottom
+}
+
+func readRadianceHeader(r *bufio.Reader) (*radianceHeader, error) {
+	magic, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(magic, "#?") {
+		return nil, errors.New("hdr: not a Radiance image")
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("hdr: unsupported format %q", strings.TrimPrefix(line, "FORMAT="))
+		}
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	f := strings.Fields(line)
+	if len(f) != 4 || f[2] != "+X" || (f[0] != "-Y" && f[0] != "+Y") {
+		return nil, fmt.Errorf("hdr: unsupported resolution %q", strings.TrimSpace(line))
+	}
+	h, err1 := strconv.Atoi(f[1])
+	w, err2 := strconv.Atoi(f[3])
+	if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("hdr: invalid resolution %q", strings.TrimSpace(line))
+	}
+	return &radianceHeader{width: w, height: h, flipV: f[0] == "+Y"}, nil
+}
+
+func configRadiance(r io.Reader) (image.Config, error) {
+	hdr, err := readRadianceHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBA64Model, Width: hdr.width, Height: hdr.height}, nil
+}
+
+// DecodeRadiance decodes a Radiance RGBE (.hdr) image,
+// the scanlines may be flat, or run-length encoded in the old or the new way.
+func DecodeRadiance(r io.Reader) (*FloatImage, error) {
+	br := bufio.NewReader(r)
+	hdr, err := readRadianceHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFloatImageSize(hdr.width, hdr.height); err != nil {
+		return nil, fmt.Errorf("hdr: %v", err)
+	}
+	// every scanline takes at least 4 bytes, a pixel or the head of the run-length encoding.
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)/4 < hdr.height {
+		return nil, fmt.Errorf("hdr: %d bytes of pixel data for %d scanlines", len(data), hdr.height)
+	}
+	br = bufio.NewReader(bytes.NewReader(data))
+
+	img := NewFloatImage(image.Rect(0, 0, hdr.width, hdr.height))
+	scanline := make([]byte, hdr.width*4)
+	for y := 0; y < hdr.height; y++ {
+		if err := readRadianceScanline(br, scanline); err != nil {
+			return nil, fmt.Errorf("hdr: scanline %d: %v", y, err)
+		}
+		row := y
+		if hdr.flipV {
+			row = hdr.height - y - 1
+		}
+		for x := 0; x < hdr.width; x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			img.SetRGB(x, row, rgbeToFloat(rgbe[0], rgbe[3]), rgbeToFloat(rgbe[1], rgbe[3]), rgbeToFloat(rgbe[2], rgbe[3]))
+		}
+	}
+	return img, nil
+}
+
+func rgbeToFloat(v, e byte) float32 {
+	if e == 0 {
+		return 0
+	}
+	return float32(math.Ldexp(float64(v), int(e)-(128+8)))
+}
+
+// readRadianceScanline reads the RGBE pixels of a scanline.
+func readRadianceScanline(r *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	head, err := r.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		return readOldScanline(r, scanline)
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return errors.New("scanline width mismatch")
+	}
+	r.Discard(4)
+
+	// the new run-length encoding stores the four components one after another.
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				n := int(count) - 128
+				if x+n > width {
+					return errors.New("bad run length")
+				}
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; n > 0; n-- {
+					scanline[x*4+c] = v
+					x++
+				}
+				continue
+			}
+			n := int(count)
+			if n == 0 || x+n > width {
+				return errors.New("bad literal length")
+			}
+			for ; n > 0; n-- {
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				scanline[x*4+c] = v
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// readOldScanline reads the flat pixels, the pixel (1, 1, 1, n) repeats the previous pixel.
+func readOldScanline(r *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	shift := uint(0)
+	for x := 0; x < width; {
+		var rgbe [4]byte
+		if _, err := io.ReadFull(r, rgbe[:]); err != nil {
+			return err
+		}
+		if rgbe[0] == 1 && rgbe[1] == 1 && rgbe[2] == 1 {
+			if x == 0 {
+				return errors.New("run without a previous pixel")
+			}
+			n := int(rgbe[3]) << shift
+			if x+n > width {
+				return errors.New("bad run length")
+			}
+			for ; n > 0; n-- {
+				copy(scanline[x*4:x*4+4], scanline[(x-1)*4:x*4])
+				x++
+			}
+			shift += 8
+			continue
+		}
+		copy(scanline[x*4:x*4+4], rgbe[:])
+		x++
+		shift = 0
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package texture
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// floatRows returns the components of the image row by row.
+func floatRows(img *FloatImage) [][]float32 {
+	rows := make([][]float32, img.Rect.Dy())
+	for y := range rows {
+		i := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		rows[y] = img.Pix[i : i+img.Rect.Dx()*3]
+	}
+	return rows
+}
+
+func compareFloatImage(t *testing.T, img *FloatImage, want [][]float32) {
+	t.Helper()
+	if size := img.Rect.Size(); size != image.Pt(len(want[0])/3, len(want)) {
+		t.Fatalf("size %v, want %dx%d", size, len(want[0])/3, len(want))
+	}
+	for y, row := range floatRows(img) {
+		for i, v := range row {
+			if v != want[y][i] {
+				t.Errorf("row %d component %d = %v, want %v", y, i, v, want[y][i])
+			}
+		}
+	}
+}
+
+func TestDecodeRadiance(t *testing.T) {
+	newRLE := func(scale float32) []float32 {
+		var row []float32
+		for x := 0; x < 8; x++ {
+			r := []float32{1, 1, 1, 1, 1, 0.5, 0.25, 0.125}[x]
+			row = append(row, r*scale, float32(x)*0.125*scale, 0)
+		}
+		return row
+	}
+	tests := []struct {
+		file string
+		want [][]float32
+	}{
+		{"flat.hdr", [][]float32{
+			{1, 0.5, 0.25, 0, 0, 0},
+			{128, 128, 128, 0.375, 0, 0.125},
+		}},
+		// +Y stores the bottom scanline first.
+		{"old_rle.hdr", [][]float32{
+			{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5},
+			{1, 0.5, 0.25, 1, 0.5, 0.25, 1, 0.5, 0.25, 1, 0.5, 0.25, 0, 0, 0},
+		}},
+		{"new_rle.hdr", [][]float32{newRLE(1), newRLE(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			// through the image package, as the loaders decode them.
+			img, format, err := image.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != "hdr" {
+				t.Errorf("format %q", format)
+			}
+			compareFloatImage(t, img.(*FloatImage), tt.want)
+		})
+	}
+}
+
+func TestDecodeRadianceInvalid(t *testing.T) {
+	head := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n"
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"huge", head + "-Y 1073741824 +X 1073741824\n", "out of range"},
+		{"wide", head + "-Y 1 +X 200000000\n\x01\x02\x03\x04", "out of range"},
+		{"truncated", head + "-Y 4096 +X 4096\n\x01\x02\x03\x04", "bytes of pixel data"},
+		{"resolution", head + "-X 2 +Y 2\n", "unsupported resolution"},
+		{"format", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n", "unsupported format"},
+		{"run first", head + "-Y 1 +X 2\n\x01\x01\x01\x02", "run without a previous pixel"},
+		{"width mismatch", head + "-Y 1 +X 8\n\x02\x02\x00\x09", "scanline width mismatch"},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeRadiance(strings.NewReader(tt.data)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package texture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// imageExtensions are the extensions of the image files taken as the layers of a directory.
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".hdr": true, ".exr": true}
+
+// Texture2DArray is a 2D array texture, its layers are sampled by the third texture coordinate in the shaders.
+type Texture2DArray struct {
+	ID uint32
+	// Columns and Rows are the grid of the sprite sheet Load splits into the layers, row by row from the top left.
+	// A zero is taken as 1.
+	Columns, Rows int
+	// Conversion selects the pixel format the layers are uploaded with, the source format is preserved by default.
+	Conversion Conversion
+	// ColorSpace is the color space of the layers, as of Texture2D.
+	ColorSpace ColorSpace
+	// Float32 uploads the HDR layers as RGB32F rather than RGB16F.
+	Float32 bool
+	sampling
+	format        PixelFormat
+	srgb          bool
+	width, height int
+	layers        int
+}
+
+func NewTexture2DArray() Texture {
+	var id uint32
+	gl.GenTextures(1, &id)
+	return &Texture2DArray{ID: id}
+}
+
+func (texture *Texture2DArray) SetParameter(name uint32, param interface{}) error {
+	return texture.setParameter(gl.TEXTURE_2D_ARRAY, name, param)
+}
+
+// SetSampler validates and applies the sampler state to the texture, which must be bound.
+func (texture *Texture2DArray) SetSampler(s Sampler) error {
+	return texture.setSampler(gl.TEXTURE_2D_ARRAY, s)
+}
+
+// Sampler returns the sampler state of the texture.
+func (texture *Texture2DArray) Sampler() Sampler {
+	return texture.state()
+}
+
+// Load loads the layers from the image files in a directory, in the order of their names,
+// or from a sprite sheet split by Columns and Rows. The layers are flipped after they are split.
+// It returns the layers stacked vertically as RGBA.
+func (texture *Texture2DArray) Load(file string, flipH, flipV bool) (*image.RGBA, error) {
+	files, err := layerFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	var stack *pixels
+	if files != nil {
+		stack, err = texture.loadFiles(files, flipH, flipV)
+	} else {
+		stack, err = texture.loadGrid(file, texture.Columns, texture.Rows, flipH, flipV)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stack.rgba(), nil
+}
+
+// LoadFiles loads the same sized image files as the layers.
+func (texture *Texture2DArray) LoadFiles(files []string, flipH, flipV bool) error {
+	_, err := texture.loadFiles(files, flipH, flipV)
+	return err
+}
+
+// LoadGrid loads the cells of the sprite sheet, columns x rows of the same size, as the layers.
+func (texture *Texture2DArray) LoadGrid(file string, columns, rows int, flipH, flipV bool) error {
+	_, err := texture.loadGrid(file, columns, rows, flipH, flipV)
+	return err
+}
+
+func (texture *Texture2DArray) loadFiles(files []string, flipH, flipV bool) (*pixels, error) {
+	data, layers, err := readLayers(files, texture.Conversion, texture.Float32)
+	if err != nil {
+		return nil, err
+	}
+	return texture.upload(data, flipLayers(layers, flipH, flipV))
+}
+
+func (texture *Texture2DArray) loadGrid(file string, columns, rows int, flipH, flipV bool) (*pixels, error) {
+	data, layers, err := readGrid(file, columns, rows, texture.Conversion, texture.Float32)
+	if err != nil {
+		return nil, err
+	}
+	return texture.upload(data, flipLayers(layers, flipH, flipV))
+}
+
+func (texture *Texture2DArray) upload(data []byte, layers []*pixels) (*pixels, error) {
+	stack, err := stackLayers(layers, "layer")
+	if err != nil {
+		return nil, err
+	}
+	p := stack
+	if texture.ColorSpace.resolve(data, stack) == ColorSpaceSRGB {
+		p = p.srgb()
+	}
+	texture.srgb = p.format.isSRGB()
+	p.texImage3D(gl.TEXTURE_2D_ARRAY, 0, len(layers))
+	setSwizzle(gl.TEXTURE_2D_ARRAY, p.format)
+	gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+
+	texture.format = p.format
+	texture.width, texture.height, texture.layers = layers[0].Width, layers[0].Height, len(layers)
+	levels := mipLevels(layers[0].Width, layers[0].Height)
+	if err := texture.setLevels(levels, levels); err != nil {
+		return nil, err
+	}
+	return stack, nil
+}
+
+// Format returns the pixel format of the loaded layers.
+func (texture *Texture2DArray) Format() PixelFormat {
+	return texture.format
+}
+
+// IsSRGB reports whether the loaded layers are sRGB encoded.
+func (texture *Texture2DArray) IsSRGB() bool {
+	return texture.srgb
+}
+
+// Size returns the width and height of the layers and the number of the layers.
+func (texture *Texture2DArray) Size() (width, height, layers int) {
+	return texture.width, texture.height, texture.layers
+}
+
+func (texture *Texture2DArray) Use() {
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, texture.ID)
+}
+
+// Texture3D is a volume texture, its slices are stacked along the R texture coordinate.
+type Texture3D struct {
+	ID uint32
+	// Columns and Rows are the grid of the slices in an image Load splits, row by row from the top left.
+	// A zero is taken as 1.
+	Columns, Rows int
+	// Conversion selects the pixel format the slices are uploaded with, the source format is preserved by default.
+	Conversion Conversion
+	// Float32 uploads the HDR slices as RGB32F rather than RGB16F.
+	Float32 bool
+	sampling
+	format               PixelFormat
+	width, height, depth int
+}
+
+func NewTexture3D() Texture {
+	var id uint32
+	gl.GenTextures(1, &id)
+	return &Texture3D{ID: id}
+}
+
+func (texture *Texture3D) SetParameter(name uint32, param interface{}) error {
+	return texture.setParameter(gl.TEXTURE_3D, name, param)
+}
+
+// SetSampler validates and applies the sampler state to the texture, which must be bound.
+func (texture *Texture3D) SetSampler(s Sampler) error {
+	return texture.setSampler(gl.TEXTURE_3D, s)
+}
+
+// Sampler returns the sampler state of the texture.
+func (texture *Texture3D) Sampler() Sampler {
+	return texture.state()
+}
+
+// Load loads the slices from the image files in a directory, in the order of their names,
+// or from an image of the slices in a grid of Columns and Rows. The slices are flipped after they are split.
+// It returns the slices stacked vertically as RGBA. The volume data is linear.
+func (texture *Texture3D) Load(file string, flipH, flipV bool) (*image.RGBA, error) {
+	files, err := layerFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	var slices []*pixels
+	if files != nil {
+		_, slices, err = readLayers(files, texture.Conversion, texture.Float32)
+	} else {
+		_, slices, err = readGrid(file, texture.Columns, texture.Rows, texture.Conversion, texture.Float32)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stack, err := texture.upload(flipLayers(slices, flipH, flipV))
+	if err != nil {
+		return nil, err
+	}
+	return stack.rgba(), nil
+}
+
+// LoadSlices loads the same sized image files as the slices, from the front.
+func (texture *Texture3D) LoadSlices(files []string, flipH, flipV bool) error {
+	_, slices, err := readLayers(files, texture.Conversion, texture.Float32)
+	if err != nil {
+		return err
+	}
+	_, err = texture.upload(flipLayers(slices, flipH, flipV))
+	return err
+}
+
+// LoadRaw loads the voxels of a raw volume file, which is width x height x depth tightly packed voxels of the format,
+// row by row and slice by slice. The 16-bit and float components are little endian, as written by most tools.
+func (texture *Texture3D) LoadRaw(file string, width, height, depth int, format PixelFormat) error {
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return fmt.Errorf("invalid volume size %dx%dx%d", width, height, depth)
+	}
+	switch format.Type {
+	case gl.UNSIGNED_BYTE, gl.UNSIGNED_SHORT, gl.FLOAT:
+	default:
+		return fmt.Errorf("unsupported voxel type 0x%x", format.Type)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if size := width * height * depth * format.Size; len(data) != size {
+		return fmt.Errorf("%s has %d bytes, expect %d for %dx%dx%d voxels", file, len(data), size, width, height, depth)
+	}
+
+	stack := &pixels{Pix: data, Width: width, Height: height * depth, format: format}
+	// the pixels are big endian.
+	_, c := stack.components()
+	for i := 0; c > 1 && i < len(data); i += c {
+		if c == 2 {
+			binary.BigEndian.PutUint16(data[i:], binary.LittleEndian.Uint16(data[i:]))
+		} else {
+			binary.BigEndian.PutUint32(data[i:], binary.LittleEndian.Uint32(data[i:]))
+		}
+	}
+	return texture.uploadStack(stack, depth)
+}
+
+func (texture *Texture3D) upload(slices []*pixels) (*pixels, error) {
+	stack, err := stackLayers(slices, "slice")
+	if err != nil {
+		return nil, err
+	}
+	if err := texture.uploadStack(stack, len(slices)); err != nil {
+		return nil, err
+	}
+	return stack, nil
+}
+
+func (texture *Texture3D) uploadStack(stack *pixels, depth int) error {
+	stack.texImage3D(gl.TEXTURE_3D, 0, depth)
+	setSwizzle(gl.TEXTURE_3D, stack.format)
+	gl.GenerateMipmap(gl.TEXTURE_3D)
+
+	texture.format = stack.format
+	texture.width, texture.height, texture.depth = stack.Width, stack.Height/depth, depth
+	levels := mipLevels(stack.Width, stack.Height/depth, depth)
+	return texture.setLevels(levels, levels)
+}
+
+// Format returns the pixel format of the loaded voxels.
+func (texture *Texture3D) Format() PixelFormat {
+	return texture.format
+}
+
+// Size returns the size of the loaded volume.
+func (texture *Texture3D) Size() (width, height, depth int) {
+	return texture.width, texture.height, texture.depth
+}
+
+func (texture *Texture3D) Use() {
+	gl.BindTexture(gl.TEXTURE_3D, texture.ID)
+}
+
+// layerFiles returns the image files in the directory sorted by name, or nil if the path is not a directory.
+func layerFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, nil
+	}
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, info := range infos {
+		if !info.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(info.Name()))] {
+			files = append(files, filepath.Join(path, info.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no image in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readLayers reads the image files, it returns the data of the first file to resolve the color space.
+func readLayers(files []string, conv Conversion, float32 bool) ([]byte, []*pixels, error) {
+	if len(files) == 0 {
+		return nil, nil, errors.New("no image files")
+	}
+	var first []byte
+	layers := make([]*pixels, len(files))
+	for i, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if layers[i], err = decodePixels(data, conv, float32); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if i == 0 {
+			first = data
+		}
+	}
+	return first, layers, nil
+}
+
+// readGrid reads the image file and splits it into the cells of the grid, row by row from the top left.
+func readGrid(file string, columns, rows int, conv Conversion, float32 bool) ([]byte, []*pixels, error) {
+	if columns == 0 {
+		columns = 1
+	}
+	if rows == 0 {
+		rows = 1
+	}
+	if columns < 0 || rows < 0 {
+		return nil, nil, fmt.Errorf("invalid grid %dx%d", columns, rows)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := decodePixels(data, conv, float32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if p.Width%columns != 0 || p.Height%rows != 0 {
+		return nil, nil, fmt.Errorf("%s: size %dx%d is not divisible by the grid %dx%d", file, p.Width, p.Height, columns, rows)
+	}
+
+	w, h := p.Width/columns, p.Height/rows
+	var cells []*pixels
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			x0, y0 := column*w, row*h
+			cells = append(cells, p.transform(w, h, func(x, y int) (int, int) { return x0 + x, y0 + y }))
+		}
+	}
+	return data, cells, nil
+}
+
+func flipLayers(layers []*pixels, flipH, flipV bool) []*pixels {
+	for i := range layers {
+		if flipH {
+			layers[i] = layers[i].flipH()
+		}
+		if flipV {
+			layers[i] = layers[i].flipV()
+		}
+	}
+	return layers
+}
+
+// stackLayers validates the layers share the size and the format, and stacks them vertically.
+func stackLayers(layers []*pixels, name string) (*pixels, error) {
+	first := layers[0]
+	for i, p := range layers {
+		if p.Width != first.Width || p.Height != first.Height {
+			return nil, fmt.Errorf("%s %d is %dx%d, expect %dx%d", name, i, p.Width, p.Height, first.Width, first.Height)
+		}
+		if p.format != first.format {
+			return nil, fmt.Errorf("%s %d has format 0x%x, expect 0x%x", name, i, p.format.InternalFormat, first.format.InternalFormat)
+		}
+	}
+
+	stack := *first
+	stack.Pix = make([]byte, 0, len(first.Pix)*len(layers))
+	for _, p := range layers {
+		stack.Pix = append(stack.Pix, p.Pix...)
+	}
+	stack.Height = first.Height * len(layers)
+	return &stack, nil
+}
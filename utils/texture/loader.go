@@ -0,0 +1,227 @@
+package texture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrLoaderClosed is the error of the images not uploaded when the loader is closed.
+var ErrLoaderClosed = errors.New("loader is closed")
+
+// Loader loads the image files to the textures in the background:
+// the images are decoded on worker goroutines, and uploaded on the GL thread by Upload,
+// which is called once a frame so the loading does not stall the rendering.
+//
+// The fields of a texture, e.g. Conversion and ColorSpace, must not be changed while it is loading.
+//
+// The pixels are uploaded by gl.TexImage2D from the memory of the decoded images. A pixel buffer object
+// would not make it cheaper unless it were mapped and filled off the GL thread, so it is not used.
+type Loader struct {
+	// Budget is the time an Upload call may spend uploading, at least one image is uploaded a call.
+	// A zero budget uploads all the decoded images.
+	Budget time.Duration
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []*Future // the images to be decoded
+	decoded []*Future // the images to be uploaded
+	pending int
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// Future is an image loading in the background, it is done when the image is uploaded or failed.
+type Future struct {
+	texture      *Texture2D
+	file         string
+	flipH, flipV bool
+
+	img  *decodedImage
+	done chan struct{}
+	rgba *image.RGBA
+	err  error
+}
+
+// NewLoader returns a loader decoding the images on the number of workers, or a worker per CPU if it is not positive.
+func NewLoader(workers int) *Loader {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	l := &Loader{}
+	l.cond = sync.NewCond(&l.mu)
+	for i := 0; i < workers; i++ {
+		l.workers.Add(1)
+		go l.work()
+	}
+	return l
+}
+
+// Load queues the image file to be loaded to the texture, as Texture2D.Load does.
+func (l *Loader) Load(texture *Texture2D, file string, flipH, flipV bool) *Future {
+	f := &Future{
+		texture: texture,
+		file:    file,
+		flipH:   flipH,
+		flipV:   flipV,
+		done:    make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		f.finish(nil, ErrLoaderClosed)
+		return f
+	}
+	l.queue = append(l.queue, f)
+	l.pending++
+	l.cond.Signal()
+	return f
+}
+
+func (l *Loader) work() {
+	defer l.workers.Done()
+	for {
+		l.mu.Lock()
+		for len(l.queue) == 0 && !l.closed {
+			l.cond.Wait()
+		}
+		if l.closed {
+			l.mu.Unlock()
+			return
+		}
+		f := l.queue[0]
+		l.queue = l.queue[1:]
+		l.mu.Unlock()
+
+		img, err := f.texture.decode(f.file, f.flipH, f.flipV)
+		var rgba *image.RGBA
+		if err == nil {
+			// the image returned is converted here rather than on the GL thread.
+			rgba = img.loaded.rgba()
+		}
+
+		l.mu.Lock()
+		switch {
+		case l.closed:
+			// Close has failed the others, and this one was not in the queues.
+			f.finish(nil, ErrLoaderClosed)
+		case err != nil:
+			l.pending--
+			f.finish(nil, err)
+		default:
+			f.img, f.rgba = img, rgba
+			l.decoded = append(l.decoded, f)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Upload uploads the decoded images to their textures within the budget, it must be called on the GL thread.
+// The textures uploaded are left bound to gl.TEXTURE_2D. It returns the number of the images uploaded.
+func (l *Loader) Upload() int {
+	start := time.Now()
+	n := 0
+	for {
+		l.mu.Lock()
+		if len(l.decoded) == 0 {
+			l.mu.Unlock()
+			return n
+		}
+		f := l.decoded[0]
+		l.decoded = l.decoded[1:]
+		l.pending--
+		l.mu.Unlock()
+
+		f.texture.Use()
+		if err := f.texture.upload(f.img); err != nil {
+			f.finish(nil, fmt.Errorf("%s: %v", f.file, err))
+		} else {
+			f.finish(f.rgba, nil)
+		}
+		f.img = nil
+		n++
+
+		if l.Budget > 0 && time.Since(start) >= l.Budget {
+			return n
+		}
+	}
+}
+
+// Pending returns the number of the images queued and not uploaded yet.
+func (l *Loader) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pending
+}
+
+// Close stops the workers, the images not uploaded yet fail with ErrLoaderClosed.
+func (l *Loader) Close() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.closed = true
+	for _, f := range l.queue {
+		f.finish(nil, ErrLoaderClosed)
+	}
+	for _, f := range l.decoded {
+		f.finish(nil, ErrLoaderClosed)
+	}
+	l.queue, l.decoded, l.pending = nil, nil, 0
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	l.workers.Wait()
+}
+
+func (f *Future) finish(rgba *image.RGBA, err error) {
+	f.rgba, f.err = rgba, err
+	close(f.done)
+}
+
+// Texture returns the texture the image is loaded to.
+func (f *Future) Texture() *Texture2D {
+	return f.texture
+}
+
+// File returns the image file.
+func (f *Future) File() string {
+	return f.file
+}
+
+// Done returns a channel closed when the image is uploaded or failed.
+// Do not wait on it on the GL thread, which uploads the images.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Ready reports whether the image is uploaded or failed.
+func (f *Future) Ready() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns the error of the loading, it is nil until the future is ready.
+func (f *Future) Err() error {
+	if !f.Ready() {
+		return nil
+	}
+	return f.err
+}
+
+// Image returns the loaded image as RGBA, as Texture2D.Load does, it is nil until the image is uploaded.
+func (f *Future) Image() *image.RGBA {
+	if !f.Ready() {
+		return nil
+	}
+	return f.rgba
+}
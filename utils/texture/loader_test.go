@@ -0,0 +1,123 @@
+package texture
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newQueueLoader returns a loader without workers, so the images stay in the queue.
+func newQueueLoader() *Loader {
+	l := &Loader{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func waitFuture(t *testing.T, f *Future) {
+	t.Helper()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s is not done", f.File())
+	}
+}
+
+func TestLoaderQueue(t *testing.T) {
+	l := newQueueLoader()
+	files := []string{"a.png", "b.png", "c.png"}
+	var futures []*Future
+	for _, file := range files {
+		futures = append(futures, l.Load(&Texture2D{}, file, false, false))
+	}
+
+	if n := l.Pending(); n != len(files) {
+		t.Errorf("Pending() = %d, want %d", n, len(files))
+	}
+	for i, f := range l.queue {
+		if f != futures[i] {
+			t.Errorf("queue[%d] = %s, want %s", i, f.File(), files[i])
+		}
+	}
+	for _, f := range futures {
+		if f.Ready() || f.Err() != nil || f.Image() != nil {
+			t.Errorf("%s: Ready() = %v, Err() = %v, Image() = %v before the decoding", f.File(), f.Ready(), f.Err(), f.Image())
+		}
+	}
+
+	l.Close()
+	for _, f := range futures {
+		if !f.Ready() || f.Err() != ErrLoaderClosed {
+			t.Errorf("%s: Ready() = %v, Err() = %v after Close, want ErrLoaderClosed", f.File(), f.Ready(), f.Err())
+		}
+	}
+	if n := l.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after Close, want 0", n)
+	}
+
+	f := l.Load(&Texture2D{}, "d.png", false, false)
+	if !f.Ready() || f.Err() != ErrLoaderClosed {
+		t.Errorf("Load after Close: Ready() = %v, Err() = %v, want ErrLoaderClosed", f.Ready(), f.Err())
+	}
+	if n := l.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after a Load of the closed loader, want 0", n)
+	}
+	l.Close()
+}
+
+func TestLoaderDecodeError(t *testing.T) {
+	l := NewLoader(2)
+	defer l.Close()
+
+	missing := l.Load(&Texture2D{}, "testdata/missing.png", false, false)
+	invalid := l.Load(&Texture2D{}, "loader_test.go", false, false)
+	waitFuture(t, missing)
+	waitFuture(t, invalid)
+
+	if err := missing.Err(); !os.IsNotExist(err) {
+		t.Errorf("missing file: Err() = %v, want a not exist error", err)
+	}
+	if err := invalid.Err(); err == nil {
+		t.Error("invalid image: Err() = nil, want a decode error")
+	}
+	if missing.Image() != nil || invalid.Image() != nil {
+		t.Error("the failed futures have images")
+	}
+	if n := l.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after the failures, want 0", n)
+	}
+}
+
+func TestLoaderPending(t *testing.T) {
+	l := NewLoader(1)
+	f := l.Load(&Texture2D{}, "testdata/box_2x2.png", false, false)
+
+	// the decoded image is pending until it is uploaded.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.mu.Lock()
+		decoded := len(l.decoded)
+		l.mu.Unlock()
+		if decoded == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the image is not decoded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := l.Pending(); n != 1 {
+		t.Errorf("Pending() = %d after the decoding, want 1", n)
+	}
+	if f.Ready() {
+		t.Errorf("the future is ready before the upload, Err() = %v", f.Err())
+	}
+
+	l.Close()
+	if !f.Ready() || f.Err() != ErrLoaderClosed || f.Image() != nil {
+		t.Errorf("after Close: Ready() = %v, Err() = %v, Image() = %v, want ErrLoaderClosed", f.Ready(), f.Err(), f.Image())
+	}
+	if n := l.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after Close, want 0", n)
+	}
+}
@@ -0,0 +1,339 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// MipmapFilter is the filter the mip levels are generated by.
+type MipmapFilter int
+
+const (
+	// MipmapGL generates the levels by gl.GenerateMipmap, the filter of which depends on the driver.
+	MipmapGL MipmapFilter = iota
+	// MipmapBox averages the pixels, it is the fastest and the blurriest.
+	MipmapBox
+	// MipmapKaiser is a windowed sinc of 3 pixels, it keeps the details sharp with little ringing.
+	MipmapKaiser
+	// MipmapLanczos is the Lanczos 3 filter, the sharpest, which may ring around the hard edges.
+	MipmapLanczos
+)
+
+func (f MipmapFilter) String() string {
+	switch f {
+	case MipmapGL:
+		return "gl"
+	case MipmapBox:
+		return "box"
+	case MipmapKaiser:
+		return "kaiser"
+	case MipmapLanczos:
+		return "lanczos"
+	}
+	return fmt.Sprintf("MipmapFilter(%d)", int(f))
+}
+
+// kernel returns the filter kernel and its support radius in the source pixels of a 1:1 scale.
+func (f MipmapFilter) kernel() (func(x float64) float64, float64) {
+	switch f {
+	case MipmapKaiser:
+		const width, alpha = 3.0, 4.0
+		return func(x float64) float64 {
+			t := x / width
+			if t*t >= 1 {
+				return 0
+			}
+			return sinc(x) * bessel0(alpha*math.Sqrt(1-t*t)) / bessel0(alpha)
+		}, width
+	case MipmapLanczos:
+		return func(x float64) float64 {
+			if x <= -3 || x >= 3 {
+				return 0
+			}
+			return sinc(x) * sinc(x/3)
+		}, 3
+	}
+	return func(x float64) float64 {
+		if x >= -0.5 && x < 0.5 {
+			return 1
+		}
+		return 0
+	}, 0.5
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// bessel0 is the zeroth order modified Bessel function of the first kind.
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 32; k++ {
+		term *= x * x / (4 * float64(k*k))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// MipmapOptions are the options of generating the mip levels on the CPU.
+type MipmapOptions struct {
+	Filter MipmapFilter
+	// SRGB filters the color components in linear space, as the sRGB encoded images must be.
+	SRGB bool
+	// AlphaCutoff preserves the coverage of the alpha tested cutout textures: the alpha of each level is scaled,
+	// so the part of its pixels of the alpha above the cutoff is as of the base level. It is disabled if zero.
+	AlphaCutoff float64
+}
+
+// GenerateMipmaps returns the mip levels below the image, down to 1x1, generated on the CPU.
+// The levels are in the color model of the pixel format the image is uploaded with by ConvertNone.
+func GenerateMipmaps(img image.Image, opts MipmapOptions) ([]image.Image, error) {
+	if opts.Filter < MipmapBox || opts.Filter > MipmapLanczos {
+		return nil, fmt.Errorf("%v is not a CPU mipmap filter", opts.Filter)
+	}
+	p := newPixels(img, ConvertNone)
+	var levels []image.Image
+	for _, level := range p.mipmaps(opts.Filter, opts.SRGB, opts.AlphaCutoff) {
+		levels = append(levels, level.image())
+	}
+	return levels, nil
+}
+
+// floatLevel is a mip level of straight alpha, linear float components, to filter the next level from.
+type floatLevel struct {
+	pix           []float64
+	width, height int
+}
+
+// mipmaps returns the levels below the pixels down to 1x1, in the format of the pixels.
+func (p *pixels) mipmaps(filter MipmapFilter, srgb bool, alphaCutoff float64) []*pixels {
+	n, _ := p.components()
+	alpha := -1
+	switch {
+	case n == 4:
+		alpha = 3
+	case n == 2 && p.format.Swizzle[3] == gl.GREEN:
+		alpha = 1
+	}
+
+	base := p.floatLevel(n, alpha, srgb)
+	coverage := 0.0
+	if alphaCutoff > 0 && alpha >= 0 {
+		coverage = base.coverage(n, alpha, alphaCutoff, 1)
+	}
+
+	var levels []*pixels
+	for level := base; level.width > 1 || level.height > 1; {
+		level = level.downsample(filter, n, alpha)
+		if coverage > 0 {
+			level.scaleAlpha(n, alpha, alphaCutoff, coverage)
+		}
+		levels = append(levels, p.fromFloatLevel(level, n, alpha, srgb))
+	}
+	return levels
+}
+
+// floatLevel converts the pixels to straight alpha, linear float components.
+func (p *pixels) floatLevel(n, alpha int, srgb bool) *floatLevel {
+	l := &floatLevel{pix: make([]float64, p.Width*p.Height*n), width: p.Width, height: p.Height}
+	for i := range l.pix {
+		l.pix[i] = p.component(i)
+	}
+	for i := 0; i < len(l.pix); i += n {
+		for c := 0; c < n; c++ {
+			if c == alpha {
+				continue
+			}
+			if p.premultiplied && alpha >= 0 && l.pix[i+alpha] > 0 {
+				l.pix[i+c] /= l.pix[i+alpha]
+			}
+			if srgb {
+				l.pix[i+c] = SRGBToLinear(l.pix[i+c])
+			}
+		}
+	}
+	return l
+}
+
+// fromFloatLevel converts the level to the pixels of the format of p.
+func (p *pixels) fromFloatLevel(l *floatLevel, n, alpha int, srgb bool) *pixels {
+	q := *p
+	q.Pix = make([]byte, l.width*l.height*p.format.Size)
+	q.Width, q.Height = l.width, l.height
+	for i := 0; i < len(l.pix); i += n {
+		for c := 0; c < n; c++ {
+			v := l.pix[i+c]
+			if c != alpha {
+				if srgb {
+					v = LinearToSRGB(math.Max(0, math.Min(1, v)))
+				}
+				// the negative lobes of the sinc filters may ring below zero, which no HDR color is.
+				if p.format.Type == gl.FLOAT {
+					v = math.Max(0, v)
+				}
+				if p.premultiplied && alpha >= 0 {
+					v *= l.pix[i+alpha]
+				}
+			}
+			q.setComponent(i+c, v)
+		}
+	}
+	return &q
+}
+
+// downsample returns the next level, half the size, filtered with the colors weighted by alpha.
+func (l *floatLevel) downsample(filter MipmapFilter, n, alpha int) *floatLevel {
+	w, h := l.width/2, l.height/2
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	src := make([]float64, len(l.pix))
+	copy(src, l.pix)
+	if alpha >= 0 {
+		premultiply(src, n, alpha)
+	}
+	kernel, support := filter.kernel()
+	rows := resample(src, l.width, l.height, n, w, true, kernel, support)
+	pix := resample(rows, w, l.height, n, h, false, kernel, support)
+	if alpha >= 0 {
+		unpremultiply(pix, n, alpha)
+	}
+	return &floatLevel{pix: pix, width: w, height: h}
+}
+
+// premultiply multiplies the colors by alpha.
+func premultiply(pix []float64, n, alpha int) {
+	for i := 0; i < len(pix); i += n {
+		for c := 0; c < n; c++ {
+			if c != alpha {
+				pix[i+c] *= pix[i+alpha]
+			}
+		}
+	}
+}
+
+// unpremultiply divides the colors by alpha, the colors of the transparent pixels are zero.
+// The alpha is clamped to [0, 1], as the negative lobes of the sinc filters may overshoot.
+func unpremultiply(pix []float64, n, alpha int) {
+	for i := 0; i < len(pix); i += n {
+		a := math.Max(0, math.Min(1, pix[i+alpha]))
+		pix[i+alpha] = a
+		for c := 0; c < n; c++ {
+			switch {
+			case c == alpha:
+			case a > 0:
+				pix[i+c] /= a
+			default:
+				pix[i+c] = 0
+			}
+		}
+	}
+}
+
+// resample resizes the width x height pixels of n components to size along x if horizontal is true, otherwise along y.
+// The edges are clamped.
+func resample(src []float64, width, height, n, size int, horizontal bool, kernel func(float64) float64, support float64) []float64 {
+	length := height
+	if horizontal {
+		length = width
+	}
+	scale := float64(length) / float64(size)
+	radius := support * math.Max(scale, 1)
+
+	// the weights of the source pixels of each destination pixel.
+	type tap struct {
+		index  int
+		weight float64
+	}
+	taps := make([][]tap, size)
+	for i := range taps {
+		center := (float64(i)+0.5)*scale - 0.5
+		sum := 0.0
+		for j := int(math.Floor(center - radius)); j <= int(math.Ceil(center+radius)); j++ {
+			weight := kernel((float64(j) - center) / math.Max(scale, 1))
+			if weight == 0 {
+				continue
+			}
+			index := j
+			if index < 0 {
+				index = 0
+			} else if index >= length {
+				index = length - 1
+			}
+			taps[i] = append(taps[i], tap{index, weight})
+			sum += weight
+		}
+		for k := range taps[i] {
+			taps[i][k].weight /= sum
+		}
+	}
+
+	var dst []float64
+	if horizontal {
+		dst = make([]float64, size*height*n)
+		for y := 0; y < height; y++ {
+			for x := 0; x < size; x++ {
+				for _, t := range taps[x] {
+					for c := 0; c < n; c++ {
+						dst[(y*size+x)*n+c] += src[(y*width+t.index)*n+c] * t.weight
+					}
+				}
+			}
+		}
+		return dst
+	}
+	dst = make([]float64, width*size*n)
+	for y := 0; y < size; y++ {
+		for _, t := range taps[y] {
+			for x := 0; x < width*n; x++ {
+				dst[y*width*n+x] += src[t.index*width*n+x] * t.weight
+			}
+		}
+	}
+	return dst
+}
+
+// coverage returns the part of the pixels whose alpha scaled is above the cutoff.
+func (l *floatLevel) coverage(n, alpha int, cutoff, scale float64) float64 {
+	covered := 0
+	for i := alpha; i < len(l.pix); i += n {
+		if l.pix[i]*scale > cutoff {
+			covered++
+		}
+	}
+	return float64(covered) / float64(l.width*l.height)
+}
+
+// scaleAlpha scales the alpha so the coverage of the level is close to the coverage.
+func (l *floatLevel) scaleAlpha(n, alpha int, cutoff, coverage float64) {
+	low, high := 0.0, 4.0
+	scale := 1.0
+	for i := 0; i < 16; i++ {
+		c := l.coverage(n, alpha, cutoff, scale)
+		if c < coverage {
+			low = scale
+		} else if c > coverage {
+			high = scale
+		} else {
+			break
+		}
+		scale = (low + high) / 2
+	}
+	for i := alpha; i < len(l.pix); i += n {
+		l.pix[i] = math.Min(1, l.pix[i]*scale)
+	}
+}
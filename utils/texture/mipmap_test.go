@@ -0,0 +1,184 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readPNG(t *testing.T, name string) image.Image {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// compareImages compares the straight alpha 8-bit components of the images within the tolerance.
+func compareImages(t *testing.T, got, want image.Image, tolerance int) {
+	t.Helper()
+	if got.Bounds().Size() != want.Bounds().Size() {
+		t.Fatalf("size %v, want %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	gb, wb := got.Bounds(), want.Bounds()
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.NRGBA)
+			for c, d := range [4][2]uint8{{g.R, w.R}, {g.G, w.G}, {g.B, w.B}, {g.A, w.A}} {
+				if diff := int(d[0]) - int(d[1]); diff < -tolerance || diff > tolerance {
+					t.Errorf("pixel (%d, %d) component %d = %d, want %d", x, y, c, d[0], d[1])
+				}
+			}
+		}
+	}
+}
+
+// alphaCoverage returns the part of the pixels of the alpha above the cutoff.
+func alphaCoverage(img image.Image, cutoff float64) float64 {
+	b := img.Bounds()
+	covered := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if float64(color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA).A)/255 > cutoff {
+				covered++
+			}
+		}
+	}
+	return float64(covered) / float64(b.Dx()*b.Dy())
+}
+
+func TestGenerateMipmapsBox(t *testing.T) {
+	levels, err := GenerateMipmaps(readPNG(t, "box_4x4.png"), MipmapOptions{Filter: MipmapBox})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 {
+		t.Fatalf("%d levels, want 2", len(levels))
+	}
+	compareImages(t, levels[0], readPNG(t, "box_2x2.png"), 1)
+	if size := levels[1].Bounds().Size(); size != image.Pt(1, 1) {
+		t.Errorf("last level size %v, want 1x1", size)
+	}
+}
+
+func TestGenerateMipmapsReference(t *testing.T) {
+	// the red, the green and the blue of edge_8x8.png are a vertical, a horizontal and a diagonal edge,
+	// the references are filtered by the kernel formulas with the edges clamped.
+	img := readPNG(t, "edge_8x8.png")
+	for _, tt := range []struct {
+		filter MipmapFilter
+		want   string
+	}{
+		{MipmapKaiser, "edge_4x4_kaiser.png"},
+		{MipmapLanczos, "edge_4x4_lanczos.png"},
+	} {
+		t.Run(tt.filter.String(), func(t *testing.T) {
+			levels, err := GenerateMipmaps(img, MipmapOptions{Filter: tt.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareImages(t, levels[0], readPNG(t, tt.want), 1)
+		})
+	}
+}
+
+func TestGenerateMipmapsFloat(t *testing.T) {
+	// a bright edge rings below zero with the sinc filters.
+	img := NewFloatImage(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 4; x < 8; x++ {
+			img.SetRGB(x, y, 100, 100, 100)
+		}
+	}
+	for _, filter := range []MipmapFilter{MipmapKaiser, MipmapLanczos} {
+		levels, err := GenerateMipmaps(img, MipmapOptions{Filter: filter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		level := levels[0].(*FloatImage)
+		max := float32(0)
+		for i, v := range level.Pix {
+			if v < 0 {
+				t.Errorf("%v: component %d = %v, want it clamped to 0", filter, i, v)
+			}
+			if v > max {
+				max = v
+			}
+		}
+		// the overshoot above the edge is kept.
+		if max <= 100 {
+			t.Errorf("%v: max component %v, want the overshoot above 100", filter, max)
+		}
+	}
+}
+
+func TestGenerateMipmapsSRGB(t *testing.T) {
+	img := readPNG(t, "srgb_checker.png")
+	tests := []struct {
+		srgb bool
+		want uint8
+	}{
+		// the linear average of black and white is 0.5, which is 0.7354 encoded in sRGB.
+		{true, 188},
+		{false, 128},
+	}
+	for _, tt := range tests {
+		for _, filter := range []MipmapFilter{MipmapBox, MipmapKaiser, MipmapLanczos} {
+			levels, err := GenerateMipmaps(img, MipmapOptions{Filter: filter, SRGB: tt.srgb})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+			want.SetNRGBA(0, 0, color.NRGBA{tt.want, tt.want, tt.want, 255})
+			t.Run(filter.String(), func(t *testing.T) {
+				compareImages(t, levels[0], want, 1)
+			})
+		}
+	}
+}
+
+func TestGenerateMipmapsAlphaCoverage(t *testing.T) {
+	// the cutoff is above the mean alpha, so the filtered levels lose coverage unless the alpha is scaled.
+	const cutoff, tolerance = 0.8, 0.03
+	img := readPNG(t, "cutout.png")
+	base := alphaCoverage(img, cutoff)
+
+	for _, filter := range []MipmapFilter{MipmapBox, MipmapKaiser, MipmapLanczos} {
+		t.Run(filter.String(), func(t *testing.T) {
+			levels, err := GenerateMipmaps(img, MipmapOptions{Filter: filter, AlphaCutoff: cutoff})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the levels down to 8x8, the smaller ones are too coarse to hit the coverage.
+			for i, level := range levels[:3] {
+				if c := alphaCoverage(level, cutoff); c < base-tolerance || c > base+tolerance {
+					t.Errorf("level %d coverage %.3f, want %.3f", i+1, c, base)
+				}
+			}
+
+			levels, err = GenerateMipmaps(img, MipmapOptions{Filter: filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c := alphaCoverage(levels[2], cutoff); c > base-tolerance {
+				t.Errorf("level 3 coverage %.3f without the cutoff, expect it to drop below %.3f", c, base-tolerance)
+			}
+		})
+	}
+}
+
+func TestGenerateMipmapsFilter(t *testing.T) {
+	if _, err := GenerateMipmaps(readPNG(t, "box_4x4.png"), MipmapOptions{Filter: MipmapGL}); err == nil {
+		t.Error("MipmapGL did not fail")
+	}
+}
@@ -0,0 +1,203 @@
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// Orientation is the EXIF orientation of an image,
+// it is named by the transform to apply on the stored pixels to display the image upright.
+type Orientation int
+
+const (
+	OrientationNormal     Orientation = 1 + iota // no transform
+	OrientationFlipH                             // flip horizontally
+	OrientationRotate180                         // rotate 180 degrees
+	OrientationFlipV                             // flip vertically
+	OrientationTranspose                         // flip along the top-left to bottom-right diagonal
+	OrientationRotate90                          // rotate 90 degrees clockwise
+	OrientationTransverse                        // flip along the top-right to bottom-left diagonal
+	OrientationRotate270                         // rotate 270 degrees clockwise
+)
+
+// FlipH returns the image flipped horizontally.
+func FlipH(img *image.RGBA) *image.RGBA {
+	return rgbaPixels(img).flipH().rgba()
+}
+
+// FlipV returns the image flipped vertically.
+func FlipV(img *image.RGBA) *image.RGBA {
+	return rgbaPixels(img).flipV().rgba()
+}
+
+// Rotate returns the image rotated clockwise by degrees, which is one of 0, 90, 180 and 270.
+func Rotate(img *image.RGBA, degrees int) (*image.RGBA, error) {
+	p, err := rgbaPixels(img).rotate(degrees)
+	if err != nil {
+		return nil, err
+	}
+	return p.rgba(), nil
+}
+
+// Orient returns the image transformed to be upright according to its EXIF orientation.
+func Orient(img *image.RGBA, o Orientation) *image.RGBA {
+	if o == OrientationNormal {
+		return img
+	}
+	return rgbaPixels(img).orient(o).rgba()
+}
+
+func (p *pixels) flipH() *pixels {
+	return p.transform(p.Width, p.Height, func(x, y int) (int, int) { return p.Width - x - 1, y })
+}
+
+func (p *pixels) flipV() *pixels {
+	q := *p
+	q.Pix = make([]byte, len(p.Pix))
+	row := p.Width * p.format.Size
+	for y := 0; y < p.Height; y++ {
+		copy(q.Pix[(p.Height-y-1)*row:(p.Height-y)*row], p.Pix[y*row:(y+1)*row])
+	}
+	return &q
+}
+
+func (p *pixels) rotate(degrees int) (*pixels, error) {
+	w, h := p.Width, p.Height
+
+	switch (degrees%360 + 360) % 360 {
+	case 0:
+		return p, nil
+	case 90:
+		return p.transform(h, w, func(x, y int) (int, int) { return y, h - x - 1 }), nil
+	case 180:
+		return p.transform(w, h, func(x, y int) (int, int) { return w - x - 1, h - y - 1 }), nil
+	case 270:
+		return p.transform(h, w, func(x, y int) (int, int) { return w - y - 1, x }), nil
+	}
+	return nil, fmt.Errorf("unsupported rotation %d, it must be a multiple of 90 degrees", degrees)
+}
+
+func (p *pixels) orient(o Orientation) *pixels {
+	w, h := p.Width, p.Height
+
+	switch o {
+	case OrientationFlipH:
+		return p.flipH()
+	case OrientationRotate180:
+		q, _ := p.rotate(180)
+		return q
+	case OrientationFlipV:
+		return p.flipV()
+	case OrientationTranspose:
+		return p.transform(h, w, func(x, y int) (int, int) { return y, x })
+	case OrientationRotate90:
+		q, _ := p.rotate(90)
+		return q
+	case OrientationTransverse:
+		return p.transform(h, w, func(x, y int) (int, int) { return w - y - 1, h - x - 1 })
+	case OrientationRotate270:
+		q, _ := p.rotate(270)
+		return q
+	}
+	return p
+}
+
+// transform returns new w x h pixels, the pixel (x, y) of which is the pixel src(x, y) of p.
+func (p *pixels) transform(w, h int, src func(x, y int) (int, int)) *pixels {
+	size := p.format.Size
+	q := *p
+	q.Pix = make([]byte, w*h*size)
+	q.Width, q.Height = w, h
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := src(x, y)
+			i := (sy*p.Width + sx) * size
+			copy(q.Pix[(y*w+x)*size:], p.Pix[i:i+size])
+		}
+	}
+	return &q
+}
+
+// ExifOrientation returns the EXIF orientation of the JPEG data,
+// OrientationNormal is returned if the data is not a JPEG or it has no orientation tag.
+func ExifOrientation(data []byte) Orientation {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return OrientationNormal
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return OrientationNormal
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // markers without length
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9: // start of scan or end of image, no more metadata
+			return OrientationNormal
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return OrientationNormal
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			if o, ok := tiffOrientation(segment[6:]); ok {
+				return o
+			}
+		}
+		i += 2 + length
+	}
+	return OrientationNormal
+}
+
+// tiffOrientation reads the orientation tag in the first IFD of the TIFF header of the EXIF data.
+func tiffOrientation(tiff []byte) (Orientation, bool) {
+	if len(tiff) < 8 {
+		return 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		// the orientation tag 0x0112 is a SHORT, the value is stored in the entry.
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != 3 {
+			return 0, false
+		}
+		o := Orientation(order.Uint16(tiff[entry+8:]))
+		if o < OrientationNormal || o > OrientationRotate270 {
+			return 0, false
+		}
+		return o, true
+	}
+	return 0, false
+}
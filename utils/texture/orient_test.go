@@ -0,0 +1,131 @@
+package texture
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// gridImage returns an image of the rows, the red component of each pixel is its value in the rows.
+func gridImage(rows [][]uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, v := range row {
+			img.SetRGBA(x, y, color.RGBA{v, 0, 0, 255})
+		}
+	}
+	return img
+}
+
+func grid(img *image.RGBA) [][]uint8 {
+	b := img.Bounds()
+	rows := make([][]uint8, b.Dy())
+	for y := range rows {
+		rows[y] = make([]uint8, b.Dx())
+		for x := range rows[y] {
+			rows[y][x] = img.RGBAAt(b.Min.X+x, b.Min.Y+y).R
+		}
+	}
+	return rows
+}
+
+func TestTransform(t *testing.T) {
+	src := [][]uint8{{1, 2}, {3, 4}, {5, 6}}
+	rotate := func(degrees int) func(*image.RGBA) *image.RGBA {
+		return func(img *image.RGBA) *image.RGBA {
+			img, err := Rotate(img, degrees)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return img
+		}
+	}
+	orient := func(o Orientation) func(*image.RGBA) *image.RGBA {
+		return func(img *image.RGBA) *image.RGBA { return Orient(img, o) }
+	}
+
+	tests := []struct {
+		name string
+		fn   func(*image.RGBA) *image.RGBA
+		want [][]uint8
+	}{
+		{"FlipH", FlipH, [][]uint8{{2, 1}, {4, 3}, {6, 5}}},
+		{"FlipV", FlipV, [][]uint8{{5, 6}, {3, 4}, {1, 2}}},
+		{"Rotate0", rotate(0), src},
+		{"Rotate90", rotate(90), [][]uint8{{5, 3, 1}, {6, 4, 2}}},
+		{"Rotate180", rotate(180), [][]uint8{{6, 5}, {4, 3}, {2, 1}}},
+		{"Rotate270", rotate(270), [][]uint8{{2, 4, 6}, {1, 3, 5}}},
+		{"RotateMinus90", rotate(-90), [][]uint8{{2, 4, 6}, {1, 3, 5}}},
+		{"OrientNormal", orient(OrientationNormal), src},
+		{"OrientFlipH", orient(OrientationFlipH), [][]uint8{{2, 1}, {4, 3}, {6, 5}}},
+		{"OrientRotate180", orient(OrientationRotate180), [][]uint8{{6, 5}, {4, 3}, {2, 1}}},
+		{"OrientFlipV", orient(OrientationFlipV), [][]uint8{{5, 6}, {3, 4}, {1, 2}}},
+		{"OrientTranspose", orient(OrientationTranspose), [][]uint8{{1, 3, 5}, {2, 4, 6}}},
+		{"OrientRotate90", orient(OrientationRotate90), [][]uint8{{5, 3, 1}, {6, 4, 2}}},
+		{"OrientTransverse", orient(OrientationTransverse), [][]uint8{{6, 4, 2}, {5, 3, 1}}},
+		{"OrientRotate270", orient(OrientationRotate270), [][]uint8{{2, 4, 6}, {1, 3, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grid(tt.fn(gridImage(src))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Rotate(gridImage(src), 45); err == nil {
+		t.Error("Rotate 45 degrees did not fail")
+	}
+}
+
+// exifJPEG returns the head of a JPEG with an APP1 segment of the orientation in the byte order.
+func exifJPEG(order binary.ByteOrder, o Orientation) []byte {
+	tiff := make([]byte, 8+2+2*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 2)
+	// an ImageWidth LONG entry before the orientation.
+	order.PutUint16(tiff[10:], 0x0100)
+	order.PutUint16(tiff[12:], 4)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint32(tiff[18:], 640)
+	order.PutUint16(tiff[22:], 0x0112)
+	order.PutUint16(tiff[24:], 3)
+	order.PutUint32(tiff[26:], 1)
+	order.PutUint16(tiff[30:], uint16(o))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(data[4:], uint16(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, 0xFF, 0xDA, 0, 2)
+}
+
+func TestExifOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := OrientationNormal; o <= OrientationRotate270; o++ {
+			if got := ExifOrientation(exifJPEG(order, o)); got != o {
+				t.Errorf("%v orientation %d: got %d", order, o, got)
+			}
+		}
+	}
+
+	invalid := map[string][]byte{
+		"not a JPEG":          []byte("\x89PNG\r\n\x1a\n"),
+		"out of range":        exifJPEG(binary.BigEndian, 9),
+		"truncated segment":   exifJPEG(binary.LittleEndian, OrientationRotate90)[:20],
+		"no APP1 before scan": {0xFF, 0xD8, 0xFF, 0xDA, 0, 2},
+	}
+	for name, data := range invalid {
+		if got := ExifOrientation(data); got != OrientationNormal {
+			t.Errorf("%s: got %d, want %d", name, got, OrientationNormal)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package texture
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ginuerzh/learnopengl/utils/glext"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Sampler is the state of how a texture is sampled in the shaders.
+// It is applied to a texture by its SetSampler, or bound to a texture unit as a SamplerObject,
+// which overrides the state of the texture bound to the unit.
+type Sampler struct {
+	WrapS, WrapT, WrapR int32 // gl.REPEAT, gl.MIRRORED_REPEAT, gl.CLAMP_TO_EDGE or gl.CLAMP_TO_BORDER
+	MinFilter           int32 // gl.NEAREST, gl.LINEAR or one of the mipmap filters, e.g. gl.LINEAR_MIPMAP_LINEAR
+	MagFilter           int32 // gl.NEAREST or gl.LINEAR
+	// Anisotropy is the max anisotropy of the anisotropic filtering, which is disabled if it is 0 or 1.
+	// It requires GL 4.6 or GL_EXT_texture_filter_anisotropic.
+	Anisotropy float32
+	LODBias    float32
+	MinLOD     float32
+	MaxLOD     float32
+	// BorderColor is the color sampled outside the texture by gl.CLAMP_TO_BORDER.
+	BorderColor [4]float32
+	// CompareMode is gl.COMPARE_REF_TO_TEXTURE to compare the depth textures with CompareFunc, e.g. for the shadow maps,
+	// or gl.NONE.
+	CompareMode int32
+	CompareFunc int32
+}
+
+// DefaultSampler returns the default sampler state of the GL.
+func DefaultSampler() Sampler {
+	return Sampler{
+		WrapS:       gl.REPEAT,
+		WrapT:       gl.REPEAT,
+		WrapR:       gl.REPEAT,
+		MinFilter:   gl.NEAREST_MIPMAP_LINEAR,
+		MagFilter:   gl.LINEAR,
+		MinLOD:      -1000,
+		MaxLOD:      1000,
+		CompareMode: gl.NONE,
+		CompareFunc: gl.LEQUAL,
+	}
+}
+
+// Mipmapped reports whether the min filter samples the mip levels.
+func (s Sampler) Mipmapped() bool {
+	switch s.MinFilter {
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		return true
+	}
+	return false
+}
+
+// Validate checks the values of the state, and their combinations.
+func (s Sampler) Validate() error {
+	for i, wrap := range []int32{s.WrapS, s.WrapT, s.WrapR} {
+		switch wrap {
+		case gl.REPEAT, gl.MIRRORED_REPEAT, gl.CLAMP_TO_EDGE, gl.CLAMP_TO_BORDER:
+		default:
+			return fmt.Errorf("invalid wrap %c mode 0x%x", "STR"[i], wrap)
+		}
+	}
+	switch s.MinFilter {
+	case gl.NEAREST, gl.LINEAR:
+	default:
+		if !s.Mipmapped() {
+			return fmt.Errorf("invalid min filter 0x%x", s.MinFilter)
+		}
+	}
+	switch s.MagFilter {
+	case gl.NEAREST, gl.LINEAR:
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		return fmt.Errorf("mag filter 0x%x can not be a mipmap filter", s.MagFilter)
+	default:
+		return fmt.Errorf("invalid mag filter 0x%x", s.MagFilter)
+	}
+	if s.Anisotropy != 0 && s.Anisotropy < 1 {
+		return fmt.Errorf("invalid anisotropy %v, it must be at least 1", s.Anisotropy)
+	}
+	if s.MinLOD > s.MaxLOD {
+		return fmt.Errorf("min LOD %v is greater than max LOD %v", s.MinLOD, s.MaxLOD)
+	}
+	switch s.CompareMode {
+	case gl.NONE, gl.COMPARE_REF_TO_TEXTURE:
+	default:
+		return fmt.Errorf("invalid compare mode 0x%x", s.CompareMode)
+	}
+	switch s.CompareFunc {
+	case gl.NEVER, gl.LESS, gl.EQUAL, gl.LEQUAL, gl.GREATER, gl.NOTEQUAL, gl.GEQUAL, gl.ALWAYS:
+	default:
+		return fmt.Errorf("invalid compare func 0x%x", s.CompareFunc)
+	}
+	return nil
+}
+
+// checkAnisotropy checks the driver supports the anisotropy, it requires a current GL context.
+func (s Sampler) checkAnisotropy() error {
+	if s.Anisotropy <= 1 {
+		return nil
+	}
+	if glext.Version() < 46 && !glext.HasExtension("GL_EXT_texture_filter_anisotropic") && !glext.HasExtension("GL_ARB_texture_filter_anisotropic") {
+		return errors.New("anisotropic filtering is not supported by the driver")
+	}
+	var max float32
+	gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+	if s.Anisotropy > max {
+		return fmt.Errorf("anisotropy %v is greater than the max %v of the driver", s.Anisotropy, max)
+	}
+	return nil
+}
+
+// apply sets the state by the setters of the texture or the sampler object parameters.
+func (s Sampler) apply(seti func(name uint32, v int32), setf func(name uint32, v float32), setfv func(name uint32, v *float32)) {
+	seti(gl.TEXTURE_WRAP_S, s.WrapS)
+	seti(gl.TEXTURE_WRAP_T, s.WrapT)
+	seti(gl.TEXTURE_WRAP_R, s.WrapR)
+	seti(gl.TEXTURE_MIN_FILTER, s.MinFilter)
+	seti(gl.TEXTURE_MAG_FILTER, s.MagFilter)
+	if s.Anisotropy > 1 {
+		setf(gl.TEXTURE_MAX_ANISOTROPY, s.Anisotropy)
+	}
+	setf(gl.TEXTURE_LOD_BIAS, s.LODBias)
+	setf(gl.TEXTURE_MIN_LOD, s.MinLOD)
+	setf(gl.TEXTURE_MAX_LOD, s.MaxLOD)
+	setfv(gl.TEXTURE_BORDER_COLOR, &s.BorderColor[0])
+	seti(gl.TEXTURE_COMPARE_MODE, s.CompareMode)
+	seti(gl.TEXTURE_COMPARE_FUNC, s.CompareFunc)
+}
+
+// set records the parameter in the state, it reports false if it is not a sampler parameter.
+func (s *Sampler) set(name uint32, v float64) bool {
+	switch name {
+	case gl.TEXTURE_WRAP_S:
+		s.WrapS = int32(v)
+	case gl.TEXTURE_WRAP_T:
+		s.WrapT = int32(v)
+	case gl.TEXTURE_WRAP_R:
+		s.WrapR = int32(v)
+	case gl.TEXTURE_MIN_FILTER:
+		s.MinFilter = int32(v)
+	case gl.TEXTURE_MAG_FILTER:
+		s.MagFilter = int32(v)
+	case gl.TEXTURE_MAX_ANISOTROPY:
+		s.Anisotropy = float32(v)
+	case gl.TEXTURE_LOD_BIAS:
+		s.LODBias = float32(v)
+	case gl.TEXTURE_MIN_LOD:
+		s.MinLOD = float32(v)
+	case gl.TEXTURE_MAX_LOD:
+		s.MaxLOD = float32(v)
+	case gl.TEXTURE_COMPARE_MODE:
+		s.CompareMode = int32(v)
+	case gl.TEXTURE_COMPARE_FUNC:
+		s.CompareFunc = int32(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// sampling is the sampler state and the mip levels of a texture, shared by the texture types.
+type sampling struct {
+	sampler  *Sampler // nil for the default state
+	baseOnly bool     // the loaded image has only the base level, and it is larger than 1x1
+}
+
+func (t *sampling) state() Sampler {
+	if t.sampler == nil {
+		return DefaultSampler()
+	}
+	return *t.sampler
+}
+
+// setSampler validates and applies the state to the texture bound to the target.
+// The mipmap min filters are rejected if the loaded image has no mip levels,
+// the state set before loading is checked when the image is uploaded.
+func (t *sampling) setSampler(target uint32, s Sampler) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := t.checkLevels(s); err != nil {
+		return err
+	}
+	if err := s.checkAnisotropy(); err != nil {
+		return err
+	}
+	s.apply(
+		func(name uint32, v int32) { gl.TexParameteri(target, name, v) },
+		func(name uint32, v float32) { gl.TexParameterf(target, name, v) },
+		func(name uint32, v *float32) { gl.TexParameterfv(target, name, v) },
+	)
+	t.sampler = &s
+	return nil
+}
+
+// setParameter sets the parameter of the texture bound to the target, the sampler parameters are recorded in the state.
+func (t *sampling) setParameter(target, name uint32, param interface{}) error {
+	var v float64
+	switch p := param.(type) {
+	case int:
+		v = float64(p)
+	case int32:
+		v = float64(p)
+	case float32:
+		v = float64(p)
+	case float64:
+		v = p
+	default:
+		return fmt.Errorf("unsupported type for %d", name)
+	}
+
+	s := t.state()
+	recorded := s.set(name, v)
+	if err := t.checkLevels(s); err != nil {
+		return err
+	}
+	switch p := param.(type) {
+	case int:
+		gl.TexParameteri(target, name, int32(p))
+	case int32:
+		gl.TexParameteri(target, name, p)
+	case float32:
+		gl.TexParameterf(target, name, p)
+	case float64:
+		gl.TexParameterf(target, name, float32(p))
+	}
+	if recorded {
+		t.sampler = &s
+	}
+	return nil
+}
+
+// setLevels records the mip levels of the uploaded image, of the full chain of which there are full,
+// and checks the state set before against them.
+func (t *sampling) setLevels(levels, full int) error {
+	t.baseOnly = levels == 1 && full > 1
+	if t.sampler == nil {
+		return nil
+	}
+	return t.checkLevels(*t.sampler)
+}
+
+// checkLevels rejects the mipmap min filters if the loaded image has no mip levels.
+func (t *sampling) checkLevels(s Sampler) error {
+	if s.Mipmapped() && t.baseOnly {
+		return fmt.Errorf("min filter 0x%x samples the mip levels, but the texture has none", s.MinFilter)
+	}
+	return nil
+}
+
+// mipLevels returns the number of the levels of a full mip chain of the size.
+func mipLevels(size ...int) int {
+	max := 1
+	for _, s := range size {
+		if s > max {
+			max = s
+		}
+	}
+	levels := 1
+	for ; max > 1; max >>= 1 {
+		levels++
+	}
+	return levels
+}
+
+// SamplerObject is a GL sampler object, it overrides the sampler state of the textures bound to the units it is bound to.
+type SamplerObject struct {
+	ID    uint32
+	state Sampler
+}
+
+// NewSamplerObject creates a sampler object of the state.
+func NewSamplerObject(s Sampler) (*SamplerObject, error) {
+	o := &SamplerObject{}
+	gl.GenSamplers(1, &o.ID)
+	if err := o.Set(s); err != nil {
+		o.Delete()
+		return nil, err
+	}
+	return o, nil
+}
+
+// Set validates and applies the state to the sampler object.
+// Unlike a texture, the sampler object does not know the mip levels of the textures it is used with.
+func (o *SamplerObject) Set(s Sampler) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkAnisotropy(); err != nil {
+		return err
+	}
+	s.apply(
+		func(name uint32, v int32) { gl.SamplerParameteri(o.ID, name, v) },
+		func(name uint32, v float32) { gl.SamplerParameterf(o.ID, name, v) },
+		func(name uint32, v *float32) { gl.SamplerParameterfv(o.ID, name, v) },
+	)
+	o.state = s
+	return nil
+}
+
+// Sampler returns the state of the sampler object.
+func (o *SamplerObject) Sampler() Sampler {
+	return o.state
+}
+
+// Bind binds the sampler object to the texture unit, e.g. 0 for gl.TEXTURE0.
+func (o *SamplerObject) Bind(unit uint32) {
+	gl.BindSampler(unit, o.ID)
+}
+
+// Delete deletes the sampler object.
+func (o *SamplerObject) Delete() {
+	gl.DeleteSamplers(1, &o.ID)
+	o.ID = 0
+}
+
+// UnbindSampler unbinds the sampler object of the texture unit, the textures bound to it use their own state.
+func UnbindSampler(unit uint32) {
+	gl.BindSampler(unit, 0)
+}
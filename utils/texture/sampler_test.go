@@ -0,0 +1,65 @@
+package texture
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestSamplerValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    func(s *Sampler)
+		valid bool
+	}{
+		{"default", func(s *Sampler) {}, true},
+		{"clamp to border", func(s *Sampler) { s.WrapS, s.WrapT, s.WrapR = gl.CLAMP_TO_BORDER, gl.CLAMP_TO_EDGE, gl.MIRRORED_REPEAT }, true},
+		{"wrap", func(s *Sampler) { s.WrapT = gl.LINEAR }, false},
+		{"min filter", func(s *Sampler) { s.MinFilter = gl.REPEAT }, false},
+		{"mag filter", func(s *Sampler) { s.MagFilter = gl.REPEAT }, false},
+		{"mipmap mag filter", func(s *Sampler) { s.MagFilter = gl.LINEAR_MIPMAP_LINEAR }, false},
+		{"no anisotropy", func(s *Sampler) { s.Anisotropy = 0 }, true},
+		{"anisotropy", func(s *Sampler) { s.Anisotropy = 16 }, true},
+		{"anisotropy below 1", func(s *Sampler) { s.Anisotropy = 0.5 }, false},
+		{"equal LOD", func(s *Sampler) { s.MinLOD, s.MaxLOD = 2, 2 }, true},
+		{"min LOD above max", func(s *Sampler) { s.MinLOD, s.MaxLOD = 3, 2 }, false},
+		{"shadow", func(s *Sampler) { s.CompareMode, s.CompareFunc = gl.COMPARE_REF_TO_TEXTURE, gl.LESS }, true},
+		{"compare mode", func(s *Sampler) { s.CompareMode = gl.LESS }, false},
+		{"compare func", func(s *Sampler) { s.CompareFunc = gl.NONE }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSampler()
+			tt.fn(&s)
+			if err := s.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestSamplingLevels(t *testing.T) {
+	s := DefaultSampler()
+	tex := sampling{sampler: &s}
+
+	// a 1x1 image has all of its mip levels.
+	if err := tex.setLevels(1, mipLevels(1, 1)); err != nil {
+		t.Errorf("1x1 image: %v", err)
+	}
+	if err := tex.setLevels(9, mipLevels(256, 256)); err != nil {
+		t.Errorf("full chain: %v", err)
+	}
+	// the state set before loading is checked by the upload.
+	if err := tex.setLevels(1, mipLevels(256, 256)); err == nil {
+		t.Error("mipmap min filter on the base level only did not fail")
+	}
+	s.MinFilter = gl.LINEAR
+	if err := tex.checkLevels(s); err != nil {
+		t.Errorf("linear min filter: %v", err)
+	}
+
+	tex = sampling{}
+	if err := tex.setLevels(1, mipLevels(256, 256)); err != nil {
+		t.Errorf("default state: %v", err)
+	}
+}
@@ -1,17 +1,17 @@
 package texture
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
+	"io/ioutil"
 	"math/rand"
 	"time"
 	// jpeg format support
 	_ "image/jpeg"
 	// png format support
 	_ "image/png"
-	"os"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
@@ -23,72 +23,148 @@ type Texture interface {
 }
 
 type Texture2D struct {
-	ID     uint32
-	params map[uint32]interface{}
+	ID uint32
+	// Rotation is the clockwise rotation in degrees applied on load: 0, 90, 180 or 270.
+	// The image is rotated after it is made upright by its EXIF orientation and before it is flipped.
+	Rotation int
+	// IgnoreOrientation disables applying the EXIF orientation of the JPEG images on load.
+	IgnoreOrientation bool
+	// Conversion selects the pixel format the images are uploaded with, the source format is preserved by default.
+	Conversion Conversion
+	// ColorSpace is the color space of the images, set it to ColorSpaceSRGB for the color textures
+	// so they are converted to linear when sampled. The images are uploaded as linear data by default.
+	ColorSpace ColorSpace
+	// Float32 uploads the HDR images as RGB32F rather than RGB16F, which takes twice the memory.
+	Float32 bool
+	// Mipmap selects the filter the mip levels are generated by, gl.GenerateMipmap by default.
+	// The levels generated on the CPU are filtered in linear space for the sRGB images.
+	Mipmap MipmapFilter
+	// AlphaCutoff preserves the alpha coverage of the mip levels generated on the CPU, see MipmapOptions.
+	AlphaCutoff float64
+	sampling
+	format PixelFormat
+	srgb   bool
 }
 
 func NewTexture2D() Texture {
 	var id uint32
 	gl.GenTextures(1, &id)
-	return &Texture2D{
-		ID:     id,
-		params: make(map[uint32]interface{}),
-	}
+	return &Texture2D{ID: id}
 }
 
 func (texture *Texture2D) SetParameter(name uint32, param interface{}) error {
-	if texture.params == nil {
-		texture.params = make(map[uint32]interface{})
+	return texture.setParameter(gl.TEXTURE_2D, name, param)
+}
+
+// SetSampler validates and applies the sampler state to the texture, which must be bound.
+func (texture *Texture2D) SetSampler(s Sampler) error {
+	return texture.setSampler(gl.TEXTURE_2D, s)
+}
+
+// Sampler returns the sampler state of the texture.
+func (texture *Texture2D) Sampler() Sampler {
+	return texture.state()
+}
+
+// Load loads the image file to the texture, it returns the loaded image as RGBA.
+// Besides JPEG and PNG, the Radiance (.hdr) and OpenEXR (.exr) images are loaded as float textures,
+// use LoadHDR to get their float pixels. The KTX, KTX2 and DDS files are loaded as by LoadCompressed,
+// and their base level is decoded to be returned, they can not be flipped or rotated.
+func (texture *Texture2D) Load(textureFile string, flipH, flipV bool) (*image.RGBA, error) {
+	p, err := texture.load(textureFile, flipH, flipV)
+	if err != nil {
+		return nil, err
 	}
-	texture.params[name] = param
+	return p.rgba(), nil
+}
 
-	switch v := param.(type) {
-	case int:
-		gl.TexParameteri(gl.TEXTURE_2D, name, int32(v))
-	case int32:
-		gl.TexParameteri(gl.TEXTURE_2D, name, v)
-	case float32:
-		gl.TexParameterf(gl.TEXTURE_2D, name, v)
-	case float64:
-		gl.TexParameterf(gl.TEXTURE_2D, name, float32(v))
-	default:
-		return fmt.Errorf("unsupported type for %d", name)
+// LoadHDR loads the HDR image file, Radiance (.hdr) or OpenEXR (.exr), to the texture.
+// It is uploaded as RGB16F, or RGB32F if Float32 is set, and its float pixels are returned.
+func (texture *Texture2D) LoadHDR(textureFile string, flipH, flipV bool) (*FloatImage, error) {
+	p, err := texture.load(textureFile, flipH, flipV)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	img, ok := p.image().(*FloatImage)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an HDR image", textureFile)
+	}
+	return img, nil
 }
 
-func (texture *Texture2D) Load(textureFile string, flipH, flipV bool) (*image.RGBA, error) {
-	f, err := os.Open(textureFile)
+// load loads the image file to the texture, it returns the pixels before the sRGB conversion.
+func (texture *Texture2D) load(textureFile string, flipH, flipV bool) (*pixels, error) {
+	img, err := texture.decode(textureFile, flipH, flipV)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	if err := texture.upload(img); err != nil {
+		return nil, fmt.Errorf("%s: %v", textureFile, err)
+	}
+	return img.loaded, nil
+}
 
-	src, _, err := image.Decode(f)
+// decodedImage is an image file decoded on the CPU, ready to be uploaded to the texture.
+type decodedImage struct {
+	loaded     *pixels   // the pixels before the sRGB conversion
+	pixels     *pixels   // the pixels to upload
+	levels     []*pixels // the mip levels below the pixels generated on the CPU
+	srgb       bool
+	compressed *CompressedImage // the KTX, KTX2 or DDS image, uploaded rather than the pixels
+}
+
+// decode reads and decodes the image file as set by the fields of the texture, it makes no GL calls.
+func (texture *Texture2D) decode(textureFile string, flipH, flipV bool) (*decodedImage, error) {
+	data, err := ioutil.ReadFile(textureFile)
 	if err != nil {
 		return nil, err
 	}
-	b := src.Bounds()
-	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return nil, fmt.Errorf("unsupported stride %d", rgba.Stride)
+	if IsCompressed(data) {
+		return decodeCompressedFile(textureFile, data, flipH || flipV || texture.Rotation != 0)
 	}
-	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
 
+	p, err := decodePixels(data, texture.Conversion, texture.Float32)
+	if err != nil {
+		return nil, err
+	}
+	if !texture.IgnoreOrientation {
+		p = p.orient(ExifOrientation(data))
+	}
+	if p, err = p.rotate(texture.Rotation); err != nil {
+		return nil, err
+	}
+	if flipH {
+		p = p.flipH()
+	}
 	if flipV {
-		rgba = texture.flipV(rgba)
+		p = p.flipV()
 	}
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA,
-		int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y),
-		0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+	img := &decodedImage{loaded: p, pixels: p}
+	if texture.ColorSpace.resolve(data, p) == ColorSpaceSRGB {
+		img.pixels = p.srgb()
+	}
+	// the 16-bit pixels are converted to linear, so the srgb is of the format uploaded.
+	img.srgb = img.pixels.format.isSRGB()
+	if texture.Mipmap != MipmapGL {
+		img.levels = img.pixels.mipmaps(texture.Mipmap, img.srgb, texture.AlphaCutoff)
+	}
+	return img, nil
+}
+
+// upload uploads the decoded image to the texture.
+func (texture *Texture2D) upload(img *decodedImage) error {
+	if img.compressed != nil {
+		return texture.uploadCompressed(img.compressed)
+	}
+
+	p := img.pixels
+	for level, q := range append([]*pixels{p}, img.levels...) {
+		q.texImage2D(gl.TEXTURE_2D, int32(level))
+	}
+	setSwizzle(gl.TEXTURE_2D, p.format)
+	texture.format = p.format
+	texture.srgb = img.srgb
 
-	/*
-		// or we can use RGB color model
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB,
-			int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y),
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(texture.rgba2RGB(rgba)),
-		)
-	*/
 	/*
 		// random texture
 		rgba = randomRGBA(512, 512)
@@ -97,42 +173,62 @@ func (texture *Texture2D) Load(textureFile string, flipH, flipV bool) (*image.RG
 			0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix),
 		)
 	*/
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_BASE_LEVEL, 0)
+	if img.levels != nil {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(len(img.levels)))
+		return texture.setLevels(len(img.levels)+1, mipLevels(p.Width, p.Height))
+	}
+	// the default max level, which may be lowered by a previous load.
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 1000)
 	gl.GenerateMipmap(gl.TEXTURE_2D)
-
-	return rgba, nil
+	return texture.setLevels(mipLevels(p.Width, p.Height), mipLevels(p.Width, p.Height))
 }
 
-func (texture *Texture2D) Use() {
-	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+// decodePixels decodes the image data to the pixels of the conversion,
+// the HDR images are decoded to RGB32F rather than RGB16F if float32 is true.
+func decodePixels(data []byte, conv Conversion, float32 bool) (*pixels, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	p := newPixels(src, conv)
+	if p.format == FormatRGB16F && float32 {
+		p.format = FormatRGB32F
+	}
+	return p, nil
 }
 
-func (texture *Texture2D) flipV(img *image.RGBA) *image.RGBA {
-	b := img.Bounds()
-	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-
-	for y := 0; y < b.Dy(); y++ {
-		copy(rgba.Pix[rgba.PixOffset(0, b.Dy()-y-1):], img.Pix[img.PixOffset(0, y):img.PixOffset(0, y+1)])
+// decodeCompressedFile parses the KTX, KTX2 or DDS file and decodes its base level.
+func decodeCompressedFile(textureFile string, data []byte, transform bool) (*decodedImage, error) {
+	if transform {
+		return nil, fmt.Errorf("%s: the compressed textures can not be flipped or rotated", textureFile)
 	}
-	return rgba
+	img, err := DecodeCompressed(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", textureFile, err)
+	}
+	if !img.Format.CanDecode() {
+		return nil, fmt.Errorf("%s: %s can not be decoded, load it by LoadCompressed", textureFile, img.Format)
+	}
+	p, err := img.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	return &decodedImage{loaded: p, pixels: p, srgb: img.Format.SRGB, compressed: img}, nil
 }
 
-func (texture *Texture2D) rgba2RGB(rgba *image.RGBA) []uint8 {
-	var rgb []uint8
+// Format returns the pixel format of the loaded image.
+func (texture *Texture2D) Format() PixelFormat {
+	return texture.format
+}
 
-	b := rgba.Bounds()
-	for y := 0; y < b.Dy(); y++ {
-		for x := 0; x < b.Dx(); x++ {
-			r, g, b, _ := rgba.At(x, y).RGBA()
-			rgb = append(rgb, uint8(r), uint8(g), uint8(b))
-		}
-	}
-	/*
-		// manually convert
-		for i := 0; i < len(rgba.Pix); i += 4 {
-			rgb = append(rgb, rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2])
-		}
-	*/
-	return rgb
+// IsSRGB reports whether the loaded image is sRGB encoded, that is the GL converts it to linear when sampled.
+func (texture *Texture2D) IsSRGB() bool {
+	return texture.srgb
+}
+
+func (texture *Texture2D) Use() {
+	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
 }
 
 func randomRGBA(sizeX, sizeY int) *image.RGBA {